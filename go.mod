@@ -3,6 +3,7 @@ module leveldb-golang
 go 1.23.7
 
 require (
+	github.com/klauspost/compress v1.18.0
 	github.com/spaolacci/murmur3 v1.1.0
 	github.com/stretchr/testify v1.10.0
 )
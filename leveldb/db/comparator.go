@@ -11,12 +11,8 @@ type IntKeyTypeSet interface {
 	~int32 | ~uint32 | ~int64 | ~uint64
 }
 
-type KeyTypeSet interface {
-	IntKeyTypeSet | ~[]byte
-}
-
 // Comparator must be thread-safe
-type Comparator[T KeyTypeSet] interface {
+type Comparator[T any] interface {
 
 	// Compare < 0 iff "a" < "b", == 0 iff "a" == "b", > 0 iff "a" > "b"
 	Compare(a, b *T) int
@@ -56,13 +52,16 @@ func NewInternalKeyCompartor[T Slice](comparator *UserKeyComparator[T]) *Interna
 }
 
 func (c *InternalKeyCompartor[T]) Compare(a, b *T) int {
-	aUserKey := ExtractUserKey((Slice)(*a))
-	bUserKey := ExtractUserKey((Slice)(*b))
+	aUserKey, aSeq, aType, aErr := ParseInternalKey((Slice)(*a))
+	bUserKey, bSeq, bType, bErr := ParseInternalKey((Slice)(*b))
+	if aErr != nil || bErr != nil {
+		return compareMalformed(*a, *b, aErr == nil, bErr == nil)
+	}
 
 	r := c.userKeyComparator.Compare((*T)(unsafe.Pointer(&aUserKey)), (*T)(unsafe.Pointer(&bUserKey)))
 	if r == 0 {
-		aTag := util.DecodeFixedUint64((Slice)(*a)[len(aUserKey):])
-		bTag := util.DecodeFixedUint64((Slice)(*b)[len(bUserKey):])
+		aTag := uint64(aSeq)<<8 | uint64(aType)
+		bTag := uint64(bSeq)<<8 | uint64(bType)
 		if aTag > bTag {
 			return -1
 		} else if aTag < bTag {
@@ -89,8 +88,11 @@ func NewMemTableKeyCompartor[T Slice](compartor *InternalKeyCompartor[T]) *MemTa
 }
 
 func (c *MemTableKeyCompartor[T]) Compare(a, b *T) int {
-	aSlice := GetLengthPrefixedSlice(*a)
-	bSlice := GetLengthPrefixedSlice(*b)
+	aSlice, _, aErr := util.GetLengthPrefixedSlice(*a)
+	bSlice, _, bErr := util.GetLengthPrefixedSlice(*b)
+	if aErr != nil || bErr != nil {
+		return compareMalformed(*a, *b, aErr == nil, bErr == nil)
+	}
 	return c.internalKeyCompartor.Compare((*T)(unsafe.Pointer(&aSlice)), (*T)(unsafe.Pointer(&bSlice)))
 }
 
@@ -98,6 +100,19 @@ func (c *MemTableKeyCompartor[T]) Name() string {
 	return "leveldb.memTableKeyComparator"
 }
 
+// compareMalformed Only reached with corrupted keys, which Compare can't report.
+// Malformed keys sort before well-formed ones and by bytes among themselves,
+// so the order stays total and a skiplist stays consistent instead of panicking.
+func compareMalformed(a, b []byte, aOk, bOk bool) int {
+	if aOk == bOk {
+		return bytes.Compare(a, b)
+	} else if aOk {
+		return 1
+	} else {
+		return -1
+	}
+}
+
 type _IntComparator[T IntKeyTypeSet]struct{}
 
 func (t *_IntComparator[T]) Compare(a, b *T) int {
@@ -8,13 +8,19 @@ type ValueType uint8
 type SequenceNumber uint64
 
 const (
-	// 注意: valueTypeDeletion 必须比 valueTypeValue 的值小
+	// 注意: valueTypeForSeek 必须是存放在 MemTable.table 中的最大的 ValueType
 	// 因为在 skiplist 中，internalKey相同时，按 tag 从大到小排序
-	// 查询时，LookupKey 中用的是 valueTypeValue
-	// 如果某个 seq 执行的是删除操作，以这个 seq 查询时，要能 Seek 到这条删除记录
+	// 查询时，LookupKey 中用的是 valueTypeForSeek
+	// 如果某个 seq 执行的是删除或者 merge 操作，以这个 seq 查询时，要能 Seek 到这条记录
 	valueTypeDeletion ValueType = iota
 	valueTypeValue
+	// valueTypeRangeDeletion 只存放在 MemTable 的 range tombstone 表中，key 为起始 userKey，value 为结束 userKey（不包含）
+	valueTypeRangeDeletion
+	// valueTypeMerge 的 value 为 merge operand，需要 MergeOperator 才能读取
+	valueTypeMerge
 	valueTypeNotExist
+
+	valueTypeForSeek = valueTypeMerge
 )
 
 type LookupKey struct {
@@ -30,7 +36,7 @@ func NewLookupKey(userKey Slice, seq SequenceNumber) *LookupKey {
 	data := make(Slice, totalLength, totalLength)
 	util.EncodeVarInt32(data, internalKeySize)
 	copy(data[internalKeySizeLength:], userKey)
-	util.EncodeFixedUint64(data[internalKeySizeLength+uint32(len(userKey)):], uint64(seq<<8)|uint64(valueTypeValue))
+	util.EncodeFixedUint64(data[internalKeySizeLength+uint32(len(userKey)):], uint64(seq<<8)|uint64(valueTypeForSeek))
 
 	return &LookupKey{
 		data:            data,
@@ -46,6 +52,21 @@ func (key *LookupKey) UserKey() Slice {
 	return key.data[key.userKeyStartIdx : len(key.data)-8]
 }
 
-func ExtractUserKey(internalKey Slice) Slice {
-	return internalKey[:len(internalKey)-8]
+func (key *LookupKey) Sequence() SequenceNumber {
+	return SequenceNumber(util.DecodeFixedUint64(key.data[len(key.data)-8:]) >> 8)
+}
+
+// ParseInternalKey Decodes an internal key (userKey + tag). Returns a Corruption error
+// (ErrBadInternalKey) if internalKey is shorter than a tag or has an unknown ValueType.
+func ParseInternalKey(internalKey Slice) (userKey Slice, seq SequenceNumber, valueType ValueType, err error) {
+	if len(internalKey) < 8 {
+		return nil, 0, 0, util.NewLevelDbError(util.ErrBadInternalKey,
+			"internal key is %d bytes, shorter than its tag", len(internalKey))
+	}
+	tag := util.DecodeFixedUint64(internalKey[len(internalKey)-8:])
+	valueType = ValueType(tag & 0xff)
+	if valueType > valueTypeForSeek {
+		return nil, 0, 0, util.NewLevelDbError(util.ErrBadInternalKey, "unknown value type %d", valueType)
+	}
+	return internalKey[:len(internalKey)-8], SequenceNumber(tag >> 8), valueType, nil
 }
@@ -0,0 +1,6 @@
+//go:build leveldb_debug
+
+package db
+
+// debugMode enables internal consistency checks that panic on violation.
+const debugMode = true
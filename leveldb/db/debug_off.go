@@ -0,0 +1,5 @@
+//go:build !leveldb_debug
+
+package db
+
+const debugMode = false
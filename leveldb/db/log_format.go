@@ -21,3 +21,14 @@ const (
 	// Header is checksum (4 bytes), length (2 bytes), type (1 byte).
 	kHeaderSize uint32 = 4 + 2 + 1
 )
+
+// LogFormatVersion Selects the checksum stored in the record header.
+// The zero value is the current format, so an unset version never falls back to the legacy one.
+type LogFormatVersion uint32
+
+const (
+	// LogFormatCrc32c Masked CRC32C, same as LevelDB. Written by LogWriter.
+	LogFormatCrc32c LogFormatVersion = 0
+	// LogFormatIEEE Unmasked IEEE CRC32, written by older versions. Only supported by LogReader.
+	LogFormatIEEE LogFormatVersion = 1
+)
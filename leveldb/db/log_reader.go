@@ -15,6 +15,7 @@ type LogReader struct {
 	source            io.ReadSeeker
 	reporter          Reporter
 	checksum          bool
+	format            LogFormatVersion
 	backingStore      []byte
 	buffer            []byte
 	eof               bool
@@ -25,10 +26,17 @@ type LogReader struct {
 }
 
 func NewLogReader(source io.ReadSeeker, reporter Reporter, checksum bool, initialOffset uint32) *LogReader {
+	return NewLogReaderWithFormat(source, reporter, checksum, initialOffset, LogFormatCrc32c)
+}
+
+// NewLogReaderWithFormat format 需要和写入 log 时使用的一致，LogFormatIEEE 用于读取旧版本写入的 log
+func NewLogReaderWithFormat(source io.ReadSeeker, reporter Reporter, checksum bool, initialOffset uint32,
+	format LogFormatVersion) *LogReader {
 	reader := &LogReader{
 		source:        source,
 		reporter:      reporter,
 		checksum:      checksum,
+		format:        format,
 		backingStore:  make([]byte, kBlockSize), // 重复利用，防止多次空间申请和释放
 		initialOffset: initialOffset,
 	}
@@ -55,8 +63,8 @@ func (lr *LogReader) SkipToInitialBlock() bool {
 		if err != nil {
 			lr.reporter.Corruption(
 				blockStartLocation,
-				util.NewLevelDbError(util.ErrSeekFileFailed,
-					"failed to seek file to offset %d, error: %v", blockStartLocation, err))
+				util.WrapLevelDbError(util.ErrSeekFileFailed, err,
+					"failed to seek file to offset %d", blockStartLocation))
 			return false
 		}
 	}
@@ -183,7 +191,7 @@ func (lr *LogReader) ReadPhysicalRecord() (Slice, KType) {
 				lr.eof = true
 				lr.reporter.Corruption(
 					kBlockSize,
-					util.NewLevelDbError(util.ErrReadFileFailed, "failed to read file, error: [%v]", err),
+					util.WrapLevelDbError(util.ErrReadFileFailed, err, "failed to read file"),
 				)
 				return nil, kEof
 			}
@@ -220,8 +228,14 @@ func (lr *LogReader) ReadPhysicalRecord() (Slice, KType) {
 	}
 
 	if lr.checksum {
-		expectedCrc := util.DecodeFixedUint32(lr.buffer[:4])
-		actualCrc := util.Crc32Value(lr.buffer[6 : 7+length])
+		var expectedCrc, actualCrc uint32
+		if lr.format == LogFormatIEEE {
+			expectedCrc = util.DecodeFixedUint32(lr.buffer[:4])
+			actualCrc = util.Crc32Value(lr.buffer[6 : 7+length])
+		} else {
+			expectedCrc = util.Unmask(util.DecodeFixedUint32(lr.buffer[:4]))
+			actualCrc = util.Crc32cValue(lr.buffer[6 : 7+length])
+		}
 		if expectedCrc != actualCrc {
 			// 将 buffer 中所有的数据都丢弃，crc 不 match，有可能是数据部分损坏了，也有可能是 length 损坏了
 			dropSize := uint32(len(lr.buffer))
@@ -2,6 +2,7 @@ package db
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"math/rand"
 	"strings"
@@ -66,14 +67,26 @@ func (sd *StringDest) SetByte(offset int, newByte byte) {
 }
 
 func (sd *StringDest) FixChecksum(headerOffset, len int) {
-	newCrc := util.Crc32Value(sd.data[headerOffset+6 : headerOffset+7+len])
-	util.EncodeFixedUint32(sd.data[headerOffset:], newCrc)
+	newCrc := util.Crc32cValue(sd.data[headerOffset+6 : headerOffset+7+len])
+	util.EncodeFixedUint32(sd.data[headerOffset:], util.Mask(newCrc))
 }
 
 func (sd *StringDest) ShrinkSize(size int) {
 	sd.data = sd.data[:len(sd.data)-size]
 }
 
+type ErrorDest struct {
+	err error
+}
+
+func (ed *ErrorDest) Write(p []byte) (n int, err error) {
+	return 0, ed.err
+}
+
+func (ed *ErrorDest) Flush() error {
+	return nil
+}
+
 type StringSource struct {
 	source *bytes.Reader
 
@@ -355,6 +368,20 @@ func TestReadError(t *testing.T) {
 	assert.Equal(t, util.ErrReadFileFailed, util.GetErrorNo(lt.reporter.Error()))
 }
 
+func TestWriteError(t *testing.T) {
+	writeErr := errors.New("disk full")
+	writer := NewLogWriter(&ErrorDest{err: writeErr})
+	err := writer.AddRecord([]byte("foo"))
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, util.ErrIOError)
+	assert.ErrorIs(t, err, writeErr)
+	assert.Equal(t, util.ErrWriteFileFailed, util.GetErrorNo(err))
+
+	writer = NewLogWriter(NewStringDest())
+	err = writer.AddRecord([]byte("foo"))
+	assert.True(t, err == nil)
+}
+
 func TestBadRecordType(t *testing.T) {
 	lt := NewLogTest(t)
 	lt.Write("foo")
@@ -405,6 +432,43 @@ func TestChecksumMismatch(t *testing.T) {
 	assert.Equal(t, util.ErrCheckCrcFailed, util.GetErrorNo(lt.reporter.Error()))
 }
 
+func TestChecksumFormat(t *testing.T) {
+	lt := NewLogTest(t)
+	lt.Write("foo")
+	// Header crc covers the type and the payload, stored masked as in LevelDB
+	data := lt.dest.Data()
+	expectedCrc := util.Mask(util.Crc32cValue([]byte{byte(kFullType), 'f', 'o', 'o'}))
+	assert.Equal(t, expectedCrc, util.DecodeFixedUint32(data))
+}
+
+func TestReadLegacyFormat(t *testing.T) {
+	// Header written by older versions: unmasked IEEE crc
+	record := []byte{0, 0, 0, 0, 3, 0, byte(kFullType), 'f', 'o', 'o'}
+	util.EncodeFixedUint32(record, util.Crc32Value(record[6:]))
+
+	source := NewStringSource()
+	source.SetData(record)
+	reporter := NewReportCollector()
+	reader := NewLogReaderWithFormat(source, reporter, true, 0, LogFormatIEEE)
+	slice, ok := reader.ReadRecord()
+	assert.True(t, ok)
+	assert.Equal(t, Slice("foo"), slice)
+	assert.Equal(t, util.ErrOk, util.GetErrorNo(reporter.Error()))
+
+	// Rejected as a checksum mismatch by the default format
+	source = NewStringSource()
+	source.SetData(record)
+	reporter = NewReportCollector()
+	reader = NewLogReader(source, reporter, true, 0)
+	_, ok = reader.ReadRecord()
+	assert.False(t, ok)
+	assert.Equal(t, util.ErrCheckCrcFailed, util.GetErrorNo(reporter.Error()))
+
+	// An unset format is the default one, not the legacy one
+	var unsetFormat LogFormatVersion
+	assert.Equal(t, LogFormatCrc32c, unsetFormat)
+}
+
 func TestUnexpectedMiddleType(t *testing.T) {
 	lt := NewLogTest(t)
 	lt.Write("foo")
@@ -565,6 +629,30 @@ func TestReadPastEnd(t *testing.T) {
 	lt.CheckOffsetPastEndReturnsNoRecords(5)
 }
 
+func FuzzLogReader(f *testing.F) {
+	dest := NewStringDest()
+	writer := NewLogWriter(dest)
+	writer.AddRecord([]byte("foo"))
+	writer.AddRecord([]byte(BigString("bar", 100)))
+	f.Add(dest.Data(), uint32(0))
+	f.Add(dest.Data(), uint32(10))
+	f.Add([]byte{}, uint32(0))
+
+	f.Fuzz(func(t *testing.T, data []byte, initialOffset uint32) {
+		source := NewStringSource()
+		source.SetData(data)
+		reporter := NewReportCollector()
+		reader := NewLogReader(source, reporter, true, initialOffset)
+		// Every record takes at least a header, so the reader must stop after that many records
+		for i := 0; i <= len(data)/int(kHeaderSize); i++ {
+			if _, ok := reader.ReadRecord(); !ok {
+				return
+			}
+		}
+		t.Fatalf("more records than headers in %d bytes", len(data))
+	})
+}
+
 func NumberToString(n int) string {
 	return fmt.Sprintf("%d.", n)
 }
@@ -10,7 +10,7 @@ var _typeCrc = make([]uint32, kMaxRecordType+1, kMaxRecordType+1)
 
 func init() {
 	for i := uint32(0); i <= kMaxRecordType; i++ {
-		_typeCrc[i] = util.Crc32Value([]byte{byte(i)})
+		_typeCrc[i] = util.Crc32cValue([]byte{byte(i)})
 	}
 }
 
@@ -38,7 +38,7 @@ func NewLogWriterWithInitialOffset(dest WriteableFile, offset uint32) *LogWriter
 	}
 }
 
-func (logWriter *LogWriter) AddRecord(slice Slice) *util.LevelDbError {
+func (logWriter *LogWriter) AddRecord(slice Slice) error {
 	startIdx, totalLength := uint32(0), uint32(len(slice))
 	start, end := true, false
 
@@ -50,7 +50,7 @@ func (logWriter *LogWriter) AddRecord(slice Slice) *util.LevelDbError {
 		if leftover < kHeaderSize {
 			if leftover > 0 {
 				if _, err := logWriter.dest.Write(make([]byte, leftover, leftover)); err != nil {
-					return util.NewLevelDbError(util.ErrWriteFileFailed, err.Error())
+					return util.WrapLevelDbError(util.ErrWriteFileFailed, err, "failed to write file")
 				}
 			}
 			logWriter.blockOffset = 0
@@ -89,7 +89,7 @@ func (logWriter *LogWriter) AddRecord(slice Slice) *util.LevelDbError {
 	return nil
 }
 
-func (logWriter *LogWriter) EmitPhysicalRecord(kType KType, data []byte) *util.LevelDbError {
+func (logWriter *LogWriter) EmitPhysicalRecord(kType KType, data []byte) error {
 	header := make([]byte, kHeaderSize, kHeaderSize)
 
 	length := len(data)
@@ -97,18 +97,19 @@ func (logWriter *LogWriter) EmitPhysicalRecord(kType KType, data []byte) *util.L
 	header[5] = uint8(length >> 8)
 	header[6] = uint8(kType)
 
-	crc := util.Crc32ValueWithInitial(_typeCrc[kType], data)
-	util.EncodeFixedUint32(header, crc)
+	// Compute the crc of the record type and the payload.
+	crc := util.Crc32cExtend(_typeCrc[kType], data)
+	util.EncodeFixedUint32(header, util.Mask(crc))
 
 	if _, err := logWriter.dest.Write(header); err != nil {
-		return util.NewLevelDbError(util.ErrWriteFileFailed, err.Error())
+		return util.WrapLevelDbError(util.ErrWriteFileFailed, err, "failed to write file")
 	}
 	if _, err := logWriter.dest.Write(data); err != nil {
-		return util.NewLevelDbError(util.ErrWriteFileFailed, err.Error())
+		return util.WrapLevelDbError(util.ErrWriteFileFailed, err, "failed to write file")
 	}
 
 	if err := logWriter.dest.Flush(); err != nil {
-		return util.NewLevelDbError(util.ErrFlushFileFailed, err.Error())
+		return util.WrapLevelDbError(util.ErrFlushFileFailed, err, "failed to flush file")
 	}
 
 	logWriter.blockOffset += kHeaderSize + uint32(length)
@@ -6,9 +6,14 @@ import (
 
 type MemTable struct {
 	table *SkipList[Slice]
+	// rangeDelTable 存放 valueTypeRangeDeletion 类型的记录，与 table 使用相同的 entry 格式
+	rangeDelTable *SkipList[Slice]
+	// rangeDelFragments 是 rangeDelTable 的索引，用于点查
+	rangeDelFragments *rangeTombstoneFragments
 
 	userKeyComparator     *UserKeyComparator[Slice]
 	internalKeyComparator *InternalKeyCompartor[Slice]
+	mergeOperator         MergeOperator
 }
 
 func NewMemTable() *MemTable {
@@ -18,12 +23,26 @@ func NewMemTable() *MemTable {
 
 	return &MemTable{
 		table:                 NewSkipList[Slice](memTableKeyComparator),
+		rangeDelTable:         NewSkipList[Slice](memTableKeyComparator),
+		rangeDelFragments:     newRangeTombstoneFragments(userKeyComparator),
 		userKeyComparator:     userKeyComparator,
 		internalKeyComparator: internalKeyComparator,
 	}
 }
 
-func (mem *MemTable) Add(seq SequenceNumber, valueType ValueType, key, value Slice) {
+func NewMemTableWithMergeOperator(mergeOperator MergeOperator) *MemTable {
+	mem := NewMemTable()
+	mem.mergeOperator = mergeOperator
+	return mem
+}
+
+// Add Returns an InvalidArgument error, and leaves mem unchanged, if an entry with the same
+// key and seq was already added, whatever its valueType (e.g. a reused sequence number).
+func (mem *MemTable) Add(seq SequenceNumber, valueType ValueType, key, value Slice) error {
+	if valueType == valueTypeRangeDeletion && mem.userKeyComparator.Compare(&key, &value) >= 0 {
+		return util.NewLevelDbError(util.ErrInvalidRange, "empty range deletion [%q, %q)", key, value)
+	}
+
 	// Format of an entry is concatenation of:
 	//  key_size     : varint32 of internal_key.size()
 	//  key bytes    : char[internal_key.size()]
@@ -49,39 +68,121 @@ func (mem *MemTable) Add(seq SequenceNumber, valueType ValueType, key, value Sli
 	currentLength += valueSizeLength
 	copy(data[currentLength:], value)
 
-	mem.table.Insert(&data)
+	table := mem.table
+	if valueType == valueTypeRangeDeletion {
+		table = mem.rangeDelTable
+	}
+	// tag 相同时按 type 降序排列，用 valueTypeForSeek 可以 Seek 到 seq 下任意 type 的记录
+	// 只有一个 writer，检查之后到插入之前不会有其他记录插入
+	lookupKey := NewLookupKey(key, seq).MemTableKey()
+	iterator := NewSkipListIterator(table)
+	iterator.Seek(&lookupKey)
+	if iterator.Valid() {
+		existingKey, existingSeq, existingType, _, err := ParseMemTableEntry(*iterator.GetKey())
+		if err != nil {
+			return err
+		}
+		if existingSeq == seq && mem.userKeyComparator.Compare(&key, &existingKey) == 0 {
+			return util.NewLevelDbError(util.ErrDuplicateEntry,
+				"entry for key %q with sequence %d already exists with type %d", key, seq, existingType)
+		}
+	}
+	table.Insert(&data)
+	if valueType == valueTypeRangeDeletion {
+		// 使用 data 中的副本，调用方可能会复用 key 和 value
+		startKey := data[internalKeySizeLength : internalKeySizeLength+keySize]
+		endKey := data[totalLength-valueSize:]
+		mem.rangeDelFragments.add(startKey, endKey, seq)
+	}
+	return nil
+}
+
+// AddRangeDeletion Deletes every user key in [startKey, endKey) visible at seq.
+// Returns an InvalidArgument error if the range is empty, i.e. startKey >= endKey.
+func (mem *MemTable) AddRangeDeletion(seq SequenceNumber, startKey, endKey Slice) error {
+	return mem.Add(seq, valueTypeRangeDeletion, startKey, endKey)
 }
 
 // Get If mem contains a value for key, return (valueTypeValue, value)
 // If mem contains a deletion for key, return (valueTypeDeletion, nil)
 // Else return (valueTypeNotExist, nil)
-func (mem *MemTable) Get(lookupKey *LookupKey) (ValueType, Slice) {
+// Merge operands found on the way are appended to mergeContext, newest first. They are folded
+// with the MergeOperator once a value, a deletion or a covering range tombstone is found.
+// Otherwise the base value may be in older data, so return (valueTypeMerge, nil) and leave the
+// merge to the caller.
+// REQUIRES: mergeContext != nil
+func (mem *MemTable) Get(lookupKey *LookupKey, mergeContext *MergeContext) (ValueType, Slice, error) {
+	// covered 时，不比 tombstoneSeq 新的记录都已经被 range tombstone 删除了
+	tombstoneSeq, covered := mem.rangeDelFragments.maxCoveringSeq(lookupKey.UserKey(), lookupKey.Sequence())
+	userKey := lookupKey.UserKey()
+
 	memTableKey := lookupKey.MemTableKey()
 	iterator := NewSkipListIterator(mem.table)
-	iterator.Seek(&memTableKey)
-	if iterator.Valid() {
-		// SkipList中的元素排序方式：先按 []byte(varint32) + []byte(userKey) 升序排序，
-		// 再按 sequence number 降序排序
-		// Seek 已经过滤掉了前缀相同但 sequence number 更大的元素了，所以不会读到后边插入的值
-		entry := iterator.GetKey()
-		keyLength, keyLengthSize := util.DecodeVarInt32(*entry)
-		userKey := lookupKey.UserKey()
-		userKeyInEntry := (*entry)[keyLengthSize : keyLengthSize+keyLength-8]
-		if mem.userKeyComparator.Compare(&userKey, &userKeyInEntry) == 0 {
-			tag := util.DecodeFixedUint64((*entry)[keyLengthSize+keyLength-8:])
-			switch ValueType(tag & 0xff) {
-			case valueTypeValue:
-				return valueTypeValue, GetLengthPrefixedSlice((*entry)[keyLengthSize+keyLength:])
-			case valueTypeDeletion:
-				return valueTypeDeletion, nil
+	// SkipList中的元素排序方式：先按 []byte(varint32) + []byte(userKey) 升序排序，
+	// 再按 sequence number 降序排序
+	// Seek 已经过滤掉了前缀相同但 sequence number 更大的元素了，所以不会读到后边插入的值
+	for iterator.Seek(&memTableKey); iterator.Valid(); iterator.Next() {
+		userKeyInEntry, seq, valueType, value, err := ParseMemTableEntry(*iterator.GetKey())
+		if err != nil {
+			return valueTypeNotExist, nil, err
+		}
+		if mem.userKeyComparator.Compare(&userKey, &userKeyInEntry) != 0 {
+			break
+		}
+		if covered && seq <= tombstoneSeq {
+			break
+		}
+		switch valueType {
+		case valueTypeValue:
+			if len(mergeContext.Operands()) == 0 {
+				return valueTypeValue, value, nil
+			}
+			return mem.merge(userKey, value, mergeContext)
+		case valueTypeDeletion:
+			if len(mergeContext.Operands()) == 0 {
+				return valueTypeDeletion, nil, nil
 			}
+			return mem.merge(userKey, nil, mergeContext)
+		case valueTypeMerge:
+			mergeContext.add(value)
 		}
 	}
 
-	return valueTypeNotExist, nil
+	if len(mergeContext.Operands()) == 0 {
+		if covered {
+			return valueTypeDeletion, nil, nil
+		}
+		return valueTypeNotExist, nil, nil
+	}
+	if covered {
+		return mem.merge(userKey, nil, mergeContext)
+	}
+	return valueTypeMerge, nil, nil
 }
 
-func GetLengthPrefixedSlice(data []byte) []byte {
-	length, lengthSize := util.DecodeVarInt32(data)
-	return data[lengthSize : lengthSize+length]
+// merge existingValue 为 nil 表示 key 已经被删除
+func (mem *MemTable) merge(userKey, existingValue Slice, mergeContext *MergeContext) (ValueType, Slice, error) {
+	value, err := mergeContext.FullMerge(mem.mergeOperator, userKey, existingValue)
+	if err != nil {
+		return valueTypeNotExist, nil, err
+	}
+	return valueTypeValue, value, nil
+}
+
+// ParseMemTableEntry Decodes an entry written by MemTable.Add. Returns a Corruption error
+// if the entry is truncated or malformed.
+func ParseMemTableEntry(entry Slice) (userKey Slice, seq SequenceNumber, valueType ValueType, value Slice, err error) {
+	internalKey, n, err := util.GetLengthPrefixedSlice(entry)
+	if err != nil {
+		return nil, 0, 0, nil, err
+	}
+	userKey, seq, valueType, err = ParseInternalKey(internalKey)
+	if err != nil {
+		return nil, 0, 0, nil, err
+	}
+	value, _, err = util.GetLengthPrefixedSlice(entry[n:])
+	if err != nil {
+		return nil, 0, 0, nil, err
+	}
+	return userKey, seq, valueType, value, nil
 }
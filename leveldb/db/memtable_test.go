@@ -1,9 +1,13 @@
 package db
 
 import (
+	"bytes"
+	"fmt"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"leveldb-golang/leveldb/util"
 )
 
 func TestSimpleReadWrite(t *testing.T) {
@@ -12,29 +16,358 @@ func TestSimpleReadWrite(t *testing.T) {
 	var valueType ValueType
 	var value Slice
 
-	valueType, _ = memTable.Get(NewLookupKey([]byte("foo"), 1))
+	valueType, _, _ = memTable.Get(NewLookupKey([]byte("foo"), 1), &MergeContext{})
 	assert.Equal(t, valueTypeNotExist, valueType)
 
 	memTable.Add(1, valueTypeValue, []byte("foo"), []byte("v1"))
-	valueType, value = memTable.Get(NewLookupKey([]byte("foo"), 1))
+	valueType, value, _ = memTable.Get(NewLookupKey([]byte("foo"), 1), &MergeContext{})
 	assert.Equal(t, valueTypeValue, valueType)
 	assert.Equal(t, []byte("v1"), []byte(value))
 
 	memTable.Add(2, valueTypeValue, []byte("foo"), []byte(""))
-	valueType, value = memTable.Get(NewLookupKey([]byte("foo"), 2))
+	valueType, value, _ = memTable.Get(NewLookupKey([]byte("foo"), 2), &MergeContext{})
 	assert.Equal(t, valueTypeValue, valueType)
 	assert.Equal(t, []byte(""), []byte(value))
 
 	memTable.Add(3, valueTypeValue, []byte("bar"), []byte("v2"))
-	valueType, value = memTable.Get(NewLookupKey([]byte("bar"), 3))
+	valueType, value, _ = memTable.Get(NewLookupKey([]byte("bar"), 3), &MergeContext{})
 	assert.Equal(t, valueTypeValue, valueType)
 	assert.Equal(t, []byte("v2"), []byte(value))
 
 	memTable.Add(4, valueTypeDeletion, []byte("foo"), []byte(""))
-	valueType, _ = memTable.Get(NewLookupKey([]byte("foo"), 4))
+	valueType, _, _ = memTable.Get(NewLookupKey([]byte("foo"), 4), &MergeContext{})
 	assert.Equal(t, valueTypeDeletion, valueType)
 
 	memTable.Add(5, valueTypeDeletion, []byte("bar"), []byte(""))
-	valueType, _ = memTable.Get(NewLookupKey([]byte("bar"), 5))
+	valueType, _, _ = memTable.Get(NewLookupKey([]byte("bar"), 5), &MergeContext{})
 	assert.Equal(t, valueTypeDeletion, valueType)
 }
+
+func TestRangeDeletion(t *testing.T) {
+	memTable := NewMemTable()
+
+	var valueType ValueType
+	var value Slice
+
+	memTable.Add(1, valueTypeValue, []byte("a"), []byte("va"))
+	memTable.Add(2, valueTypeValue, []byte("b"), []byte("vb"))
+	memTable.Add(3, valueTypeValue, []byte("c"), []byte("vc"))
+	memTable.AddRangeDeletion(4, []byte("a"), []byte("c"))
+
+	// [a, c) is deleted, c is not
+	valueType, _, _ = memTable.Get(NewLookupKey([]byte("a"), 4), &MergeContext{})
+	assert.Equal(t, valueTypeDeletion, valueType)
+	valueType, _, _ = memTable.Get(NewLookupKey([]byte("b"), 4), &MergeContext{})
+	assert.Equal(t, valueTypeDeletion, valueType)
+	valueType, value, _ = memTable.Get(NewLookupKey([]byte("c"), 4), &MergeContext{})
+	assert.Equal(t, valueTypeValue, valueType)
+	assert.Equal(t, []byte("vc"), []byte(value))
+
+	// Keys in range without a value are reported as deleted
+	valueType, _, _ = memTable.Get(NewLookupKey([]byte("aa"), 4), &MergeContext{})
+	assert.Equal(t, valueTypeDeletion, valueType)
+
+	// Older snapshots do not see the tombstone
+	valueType, value, _ = memTable.Get(NewLookupKey([]byte("b"), 3), &MergeContext{})
+	assert.Equal(t, valueTypeValue, valueType)
+	assert.Equal(t, []byte("vb"), []byte(value))
+	valueType, _, _ = memTable.Get(NewLookupKey([]byte("aa"), 3), &MergeContext{})
+	assert.Equal(t, valueTypeNotExist, valueType)
+
+	// Newer writes are not affected by the tombstone
+	memTable.Add(5, valueTypeValue, []byte("b"), []byte("vb2"))
+	valueType, value, _ = memTable.Get(NewLookupKey([]byte("b"), 5), &MergeContext{})
+	assert.Equal(t, valueTypeValue, valueType)
+	assert.Equal(t, []byte("vb2"), []byte(value))
+	valueType, _, _ = memTable.Get(NewLookupKey([]byte("a"), 5), &MergeContext{})
+	assert.Equal(t, valueTypeDeletion, valueType)
+}
+
+func TestRangeDeletionAtSequenceZero(t *testing.T) {
+	memTable := NewMemTable()
+	assert.Nil(t, memTable.AddRangeDeletion(0, []byte("a"), []byte("z")))
+	valueType, _, _ := memTable.Get(NewLookupKey([]byte("q"), 10), &MergeContext{})
+	assert.Equal(t, valueTypeDeletion, valueType)
+	valueType, _, _ = memTable.Get(NewLookupKey([]byte("z"), 10), &MergeContext{})
+	assert.Equal(t, valueTypeNotExist, valueType)
+
+	// Newer writes are not affected
+	memTable.Add(1, valueTypeValue, []byte("q"), []byte("v"))
+	valueType, _, _ = memTable.Get(NewLookupKey([]byte("q"), 10), &MergeContext{})
+	assert.Equal(t, valueTypeValue, valueType)
+}
+
+func TestAddDuplicate(t *testing.T) {
+	memTable := NewMemTable()
+	assert.Nil(t, memTable.Add(1, valueTypeValue, []byte("foo"), []byte("v1")))
+
+	// Same key and sequence number
+	err := memTable.Add(1, valueTypeValue, []byte("foo"), []byte("v2"))
+	assert.ErrorIs(t, err, util.ErrInvalidArgument)
+	assert.Equal(t, util.ErrDuplicateEntry, util.GetErrorNo(err))
+	_, value, _ := memTable.Get(NewLookupKey([]byte("foo"), 1), &MergeContext{})
+	assert.Equal(t, []byte("v1"), []byte(value))
+
+	// Same key and sequence number with a different type
+	err = memTable.Add(1, valueTypeDeletion, []byte("foo"), nil)
+	assert.Equal(t, util.ErrDuplicateEntry, util.GetErrorNo(err))
+	err = memTable.Add(1, valueTypeMerge, []byte("foo"), []byte("m"))
+	assert.Equal(t, util.ErrDuplicateEntry, util.GetErrorNo(err))
+	valueType, value, _ := memTable.Get(NewLookupKey([]byte("foo"), 1), &MergeContext{})
+	assert.Equal(t, valueTypeValue, valueType)
+	assert.Equal(t, []byte("v1"), []byte(value))
+
+	// A different key or sequence is a different entry
+	assert.Nil(t, memTable.Add(1, valueTypeDeletion, []byte("bar"), nil))
+	assert.Nil(t, memTable.Add(2, valueTypeDeletion, []byte("foo"), nil))
+	err = memTable.Add(2, valueTypeValue, []byte("foo"), []byte("v2"))
+	assert.Equal(t, util.ErrDuplicateEntry, util.GetErrorNo(err))
+
+	assert.Nil(t, memTable.AddRangeDeletion(3, []byte("a"), []byte("b")))
+	err = memTable.AddRangeDeletion(3, []byte("a"), []byte("c"))
+	assert.Equal(t, util.ErrDuplicateEntry, util.GetErrorNo(err))
+}
+
+func TestAddEmptyRangeDeletion(t *testing.T) {
+	memTable := NewMemTable()
+	err := memTable.AddRangeDeletion(1, []byte("b"), []byte("b"))
+	assert.ErrorIs(t, err, util.ErrInvalidArgument)
+	assert.Equal(t, util.ErrInvalidRange, util.GetErrorNo(err))
+	err = memTable.AddRangeDeletion(1, []byte("c"), []byte("a"))
+	assert.Equal(t, util.ErrInvalidRange, util.GetErrorNo(err))
+
+	// Nothing was stored
+	valueType, _, _ := memTable.Get(NewLookupKey([]byte("b"), 1), &MergeContext{})
+	assert.Equal(t, valueTypeNotExist, valueType)
+}
+
+// appendOperator joins operands onto the existing value with ","
+type appendOperator struct{}
+
+func (*appendOperator) FullMerge(key, existingValue Slice, operands []Slice) (Slice, bool) {
+	parts := make([][]byte, 0, len(operands)+1)
+	if existingValue != nil {
+		parts = append(parts, existingValue)
+	}
+	for _, operand := range operands {
+		if len(operand) == 0 {
+			return nil, false
+		}
+		parts = append(parts, operand)
+	}
+	return bytes.Join(parts, []byte(",")), true
+}
+
+func (*appendOperator) PartialMerge(key, leftOperand, rightOperand Slice) (Slice, bool) {
+	return bytes.Join([][]byte{leftOperand, rightOperand}, []byte(",")), true
+}
+
+func (*appendOperator) Name() string {
+	return "test.appendOperator"
+}
+
+func TestMerge(t *testing.T) {
+	memTable := NewMemTableWithMergeOperator(&appendOperator{})
+
+	var valueType ValueType
+	var value Slice
+	var err error
+
+	// Operands without a base value are left to the caller, newest first
+	memTable.Add(1, valueTypeMerge, []byte("foo"), []byte("a"))
+	memTable.Add(2, valueTypeMerge, []byte("foo"), []byte("b"))
+	mergeContext := &MergeContext{}
+	valueType, _, err = memTable.Get(NewLookupKey([]byte("foo"), 2), mergeContext)
+	assert.Nil(t, err)
+	assert.Equal(t, valueTypeMerge, valueType)
+	assert.Equal(t, []Slice{Slice("b"), Slice("a")}, mergeContext.Operands())
+	value, err = mergeContext.FullMerge(memTable.mergeOperator, []byte("foo"), []byte("base"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("base,a,b"), []byte(value))
+
+	// Snapshot only sees older operands
+	mergeContext = &MergeContext{}
+	valueType, _, err = memTable.Get(NewLookupKey([]byte("foo"), 1), mergeContext)
+	assert.Nil(t, err)
+	assert.Equal(t, valueTypeMerge, valueType)
+	assert.Equal(t, []Slice{Slice("a")}, mergeContext.Operands())
+
+	// Operands collected from newer data are applied onto the value found in mem
+	memTable.Add(3, valueTypeValue, []byte("bar"), []byte("v"))
+	mergeContext = &MergeContext{}
+	mergeContext.add([]byte("x"))
+	valueType, value, err = memTable.Get(NewLookupKey([]byte("bar"), 3), mergeContext)
+	assert.Nil(t, err)
+	assert.Equal(t, valueTypeValue, valueType)
+	assert.Equal(t, []byte("v,x"), []byte(value))
+
+	// Operands stop at the newest Put
+	memTable.Add(3, valueTypeValue, []byte("foo"), []byte("v"))
+	memTable.Add(4, valueTypeMerge, []byte("foo"), []byte("c"))
+	valueType, value, err = memTable.Get(NewLookupKey([]byte("foo"), 4), &MergeContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, valueTypeValue, valueType)
+	assert.Equal(t, []byte("v,c"), []byte(value))
+
+	// Operands stop at the newest Delete
+	memTable.Add(5, valueTypeDeletion, []byte("foo"), nil)
+	valueType, _, err = memTable.Get(NewLookupKey([]byte("foo"), 5), &MergeContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, valueTypeDeletion, valueType)
+	memTable.Add(6, valueTypeMerge, []byte("foo"), []byte("d"))
+	valueType, value, err = memTable.Get(NewLookupKey([]byte("foo"), 6), &MergeContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, valueTypeValue, valueType)
+	assert.Equal(t, []byte("d"), []byte(value))
+
+	// Operands stop at a range tombstone
+	memTable.Add(7, valueTypeValue, []byte("g"), []byte("v"))
+	memTable.AddRangeDeletion(8, []byte("a"), []byte("z"))
+	memTable.Add(9, valueTypeMerge, []byte("g"), []byte("e"))
+	valueType, value, err = memTable.Get(NewLookupKey([]byte("g"), 9), &MergeContext{})
+	assert.Nil(t, err)
+	assert.Equal(t, valueTypeValue, valueType)
+	assert.Equal(t, []byte("e"), []byte(value))
+
+	// Merge failures are reported as corruption
+	memTable.Add(10, valueTypeMerge, []byte("foo"), []byte(""))
+	_, _, err = memTable.Get(NewLookupKey([]byte("foo"), 10), &MergeContext{})
+	assert.ErrorIs(t, err, util.ErrCorruption)
+	assert.Equal(t, util.ErrMergeFailed, util.GetErrorNo(err))
+}
+
+func TestMergeWithoutOperator(t *testing.T) {
+	memTable := NewMemTable()
+	memTable.Add(1, valueTypeMerge, []byte("foo"), []byte("a"))
+	mergeContext := &MergeContext{}
+	_, _, err := memTable.Get(NewLookupKey([]byte("foo"), 1), mergeContext)
+	assert.Nil(t, err)
+	_, err = mergeContext.FullMerge(memTable.mergeOperator, []byte("foo"), nil)
+	assert.ErrorIs(t, err, util.ErrInvalidArgument)
+	assert.Equal(t, util.ErrMergeOperatorMissing, util.GetErrorNo(err))
+}
+
+func newBenchmarkMemTable(n int) *MemTable {
+	memTable := NewMemTable()
+	for i := 0; i < n; i++ {
+		memTable.Add(SequenceNumber(i+1), valueTypeValue, []byte(NumberToString(i)), []byte("value"))
+	}
+	return memTable
+}
+
+func BenchmarkMemTableGetWithRangeDeletions(b *testing.B) {
+	memTable := newBenchmarkMemTable(100000)
+	// Many small tombstones, e.g. purging tenants one at a time
+	for i := 0; i < 10000; i++ {
+		memTable.AddRangeDeletion(SequenceNumber(100000+i+1),
+			[]byte(NumberToString(i*10)), []byte(NumberToString(i*10+5)))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		memTable.Get(NewLookupKey([]byte(NumberToString(i%100000)), 200000), &MergeContext{})
+	}
+}
+
+// newNestedRangeDeletionMemTable Adds n tombstones [i, 2n-i), each one overlapping all the others.
+func newNestedRangeDeletionMemTable(n int) *MemTable {
+	memTable := NewMemTable()
+	for i := 0; i < n; i++ {
+		memTable.AddRangeDeletion(SequenceNumber(i+1),
+			[]byte(fmt.Sprintf("%08d", i)), []byte(fmt.Sprintf("%08d", 2*n-i)))
+	}
+	return memTable
+}
+
+// retainedHeap Returns the heap still in use by what build returns.
+func retainedHeap(build func() any) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	result := build()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(result)
+	if after.HeapAlloc < before.HeapAlloc {
+		return 0
+	}
+	return after.HeapAlloc - before.HeapAlloc
+}
+
+func TestNestedRangeDeletionMemory(t *testing.T) {
+	const n = 4000
+	retained := retainedHeap(func() any {
+		return newNestedRangeDeletionMemTable(n)
+	})
+	// Sequence numbers are shared between fragments: memory grows with the number of
+	// tombstones, not fragments x tombstones
+	assert.Less(t, retained/n, uint64(2048), "retained %d bytes for %d tombstones", retained, n)
+
+	memTable := newNestedRangeDeletionMemTable(n)
+	for _, i := range []int{0, n / 2, n - 1, n, 2*n - 1} {
+		// key i is covered by tombstones 0..i, key 2n-1-i too
+		expectedSeq := SequenceNumber(min(i, 2*n-1-i) + 1)
+		seq, found := memTable.rangeDelFragments.maxCoveringSeq([]byte(fmt.Sprintf("%08d", i)), SequenceNumber(n))
+		assert.True(t, found, "key %d", i)
+		assert.Equal(t, expectedSeq, seq, "key %d", i)
+	}
+}
+
+func BenchmarkMemTableAddNestedRangeDeletions(b *testing.B) {
+	const n = 4000
+	var retained uint64
+	for i := 0; i < b.N; i++ {
+		retained = retainedHeap(func() any {
+			return newNestedRangeDeletionMemTable(n)
+		})
+	}
+	b.ReportMetric(float64(retained)/n, "retained-B/tombstone")
+}
+
+func BenchmarkMemTableForwardScan(b *testing.B) {
+	memTable := newBenchmarkMemTable(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		iter := NewSkipListIterator(memTable.table)
+		for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		}
+	}
+}
+
+func BenchmarkMemTableReverseScan(b *testing.B) {
+	memTable := newBenchmarkMemTable(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		iter := NewSkipListIterator(memTable.table)
+		for iter.SeekToLast(); iter.Valid(); iter.Prev() {
+		}
+	}
+}
+
+func FuzzMemTableEntry(f *testing.F) {
+	memTable := NewMemTable()
+	memTable.Add(1, valueTypeValue, []byte("foo"), []byte("v1"))
+	memTable.AddRangeDeletion(2, []byte("a"), []byte("z"))
+	for _, table := range []*SkipList[Slice]{memTable.table, memTable.rangeDelTable} {
+		iter := NewSkipListIterator(table)
+		for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+			f.Add([]byte(*iter.GetKey()), []byte{})
+		}
+	}
+	f.Add([]byte{0x80}, []byte{0x08, 0, 0, 0, 0, 0, 0, 0, 0xff})
+
+	comparator := NewMemTableKeyCompartor[Slice](NewInternalKeyCompartor[Slice](NewUserKeyComparator[Slice]()))
+	f.Fuzz(func(t *testing.T, a, b []byte) {
+		// Corrupted entries must be reported, not panic
+		if _, _, valueType, _, err := ParseMemTableEntry(a); err == nil {
+			assert.LessOrEqual(t, valueType, valueTypeForSeek)
+		} else {
+			assert.ErrorIs(t, err, util.ErrCorruption)
+		}
+
+		aSlice, bSlice := Slice(a), Slice(b)
+		assert.Equal(t, 0, comparator.Compare(&aSlice, &aSlice))
+		ab, ba := comparator.Compare(&aSlice, &bSlice), comparator.Compare(&bSlice, &aSlice)
+		assert.Equal(t, ab < 0, ba > 0)
+		assert.Equal(t, ab == 0, ba == 0)
+	})
+}
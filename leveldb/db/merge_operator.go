@@ -0,0 +1,61 @@
+package db
+
+import (
+	"slices"
+
+	"leveldb-golang/leveldb/util"
+)
+
+// MergeOperator folds merge operands written with valueTypeMerge into a value,
+// so read-modify-write updates (counters, appends) don't need a read first.
+// MergeOperator must be thread-safe
+type MergeOperator interface {
+
+	// FullMerge Combines existingValue (nil if the key has no value or was deleted) with
+	// operands, ordered from oldest to newest. Returns false if the operands can't be applied,
+	// which is reported as corruption.
+	FullMerge(key, existingValue Slice, operands []Slice) (Slice, bool)
+
+	// PartialMerge Combines two adjacent operands into one, leftOperand being the older one.
+	// Returns false if they can't be combined without the existing value.
+	PartialMerge(key, leftOperand, rightOperand Slice) (Slice, bool)
+
+	// Name of the merge operator. Prevent mismatch (i.e., a database written with one merge operator
+	// and read with another)
+	Name() string
+}
+
+// MergeContext Collects the merge operands of a key while a Get goes from newer to older data
+// (memtable, then tables), until a value, a deletion or the end of the data is found.
+type MergeContext struct {
+	operands []Slice // 从新到旧排列
+}
+
+// Operands Returns the operands collected so far, newest first.
+func (c *MergeContext) Operands() []Slice {
+	return c.operands
+}
+
+func (c *MergeContext) add(operand Slice) {
+	c.operands = append(c.operands, operand)
+}
+
+// FullMerge Applies the collected operands onto existingValue, nil if the key has no value
+// or was deleted. Returns an error if mergeOperator is nil or fails.
+func (c *MergeContext) FullMerge(mergeOperator MergeOperator, key, existingValue Slice) (Slice, error) {
+	if len(c.operands) == 0 {
+		return existingValue, nil
+	}
+	if mergeOperator == nil {
+		return nil, util.NewLevelDbError(util.ErrMergeOperatorMissing,
+			"found merge operands for key %q without a merge operator", key)
+	}
+	operands := slices.Clone(c.operands)
+	slices.Reverse(operands)
+	value, ok := mergeOperator.FullMerge(key, existingValue, operands)
+	if !ok {
+		return nil, util.NewLevelDbError(util.ErrMergeFailed,
+			"merge operator %s failed for key %q", mergeOperator.Name(), key)
+	}
+	return value, nil
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"bytes"
+	"fmt"
+
+	"leveldb-golang/leveldb/util"
+)
+
+// PrefixExtractor maps a user key to the prefix used by prefix filters and prefix-seek iteration.
+// PrefixExtractor must be thread-safe
+type PrefixExtractor interface {
+
+	// Transform Returns the prefix of key.
+	// REQUIRES: InDomain(key)
+	Transform(key Slice) Slice
+
+	// InDomain Returns true iff key has a prefix. Keys out of domain are never excluded by a prefix filter.
+	InDomain(key Slice) bool
+
+	// Name of the prefix extractor. Filters built with one extractor can't be probed with another.
+	Name() string
+}
+
+type fixedPrefixExtractor struct {
+	length int
+}
+
+// NewFixedPrefixExtractor Uses the first length bytes of a key as its prefix.
+// Keys shorter than length are out of domain.
+func NewFixedPrefixExtractor(length int) PrefixExtractor {
+	return &fixedPrefixExtractor{
+		length: length,
+	}
+}
+
+func (e *fixedPrefixExtractor) Transform(key Slice) Slice {
+	return key[:e.length]
+}
+
+func (e *fixedPrefixExtractor) InDomain(key Slice) bool {
+	return len(key) >= e.length
+}
+
+func (e *fixedPrefixExtractor) Name() string {
+	return fmt.Sprintf("leveldb.FixedPrefix.%d", e.length)
+}
+
+type delimiterPrefixExtractor struct {
+	delimiter byte
+}
+
+// NewDelimiterPrefixExtractor Uses everything up to and including the first delimiter as the prefix,
+// e.g. "tenant1/" for "tenant1/key" with delimiter '/'. Keys without the delimiter are out of domain.
+func NewDelimiterPrefixExtractor(delimiter byte) PrefixExtractor {
+	return &delimiterPrefixExtractor{
+		delimiter: delimiter,
+	}
+}
+
+func (e *delimiterPrefixExtractor) Transform(key Slice) Slice {
+	return key[:bytes.IndexByte(key, e.delimiter)+1]
+}
+
+func (e *delimiterPrefixExtractor) InDomain(key Slice) bool {
+	return bytes.IndexByte(key, e.delimiter) >= 0
+}
+
+func (e *delimiterPrefixExtractor) Name() string {
+	return fmt.Sprintf("leveldb.DelimiterPrefix.%q", e.delimiter)
+}
+
+type prefixFilterPolicy struct {
+	extractor PrefixExtractor
+	policy    util.FilterPolicy
+}
+
+// NewPrefixFilterPolicy Builds filters from the prefixes of keys instead of the whole keys,
+// so a filter can tell whether any key with a given prefix may be present.
+func NewPrefixFilterPolicy(extractor PrefixExtractor, policy util.FilterPolicy) util.FilterPolicy {
+	return &prefixFilterPolicy{
+		extractor: extractor,
+		policy:    policy,
+	}
+}
+
+func (p *prefixFilterPolicy) Name() string {
+	return p.policy.Name() + ":" + p.extractor.Name()
+}
+
+func (p *prefixFilterPolicy) CreateFilter(keys [][]byte, dst []byte) []byte {
+	prefixes := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		if !p.extractor.InDomain(key) {
+			continue
+		}
+		// keys 是有序的，相同的 prefix 一定相邻
+		prefix := p.extractor.Transform(key)
+		if len(prefixes) > 0 && bytes.Equal(prefixes[len(prefixes)-1], prefix) {
+			continue
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return p.policy.CreateFilter(prefixes, dst)
+}
+
+// KeyMayMatch key 可以是一个完整的 key，也可以是一个 prefix
+func (p *prefixFilterPolicy) KeyMayMatch(key []byte, filter []byte) bool {
+	if !p.extractor.InDomain(key) {
+		return true
+	}
+	return p.policy.KeyMayMatch(p.extractor.Transform(key), filter)
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"leveldb-golang/leveldb/util"
+)
+
+func TestFixedPrefixExtractor(t *testing.T) {
+	extractor := NewFixedPrefixExtractor(3)
+	assert.True(t, extractor.InDomain([]byte("abcd")))
+	assert.True(t, extractor.InDomain([]byte("abc")))
+	assert.False(t, extractor.InDomain([]byte("ab")))
+	assert.Equal(t, Slice("abc"), extractor.Transform([]byte("abcd")))
+}
+
+func TestDelimiterPrefixExtractor(t *testing.T) {
+	extractor := NewDelimiterPrefixExtractor('/')
+	assert.True(t, extractor.InDomain([]byte("tenant1/key")))
+	assert.False(t, extractor.InDomain([]byte("tenant1")))
+	assert.Equal(t, Slice("tenant1/"), extractor.Transform([]byte("tenant1/key")))
+	assert.Equal(t, Slice("/"), extractor.Transform([]byte("/key")))
+}
+
+func TestPrefixFilterPolicy(t *testing.T) {
+	policy := NewPrefixFilterPolicy(NewDelimiterPrefixExtractor('/'), util.NewBloomFilterPolicy(10))
+	keys := [][]byte{
+		[]byte("noprefix"),
+		[]byte("tenant1/a"),
+		[]byte("tenant1/b"),
+		[]byte("tenant2/a"),
+	}
+	filter := policy.CreateFilter(keys, nil)
+
+	assert.True(t, policy.KeyMayMatch([]byte("tenant1/"), filter))
+	assert.True(t, policy.KeyMayMatch([]byte("tenant1/zzz"), filter))
+	assert.True(t, policy.KeyMayMatch([]byte("tenant2/a"), filter))
+	assert.False(t, policy.KeyMayMatch([]byte("tenant3/a"), filter))
+	// Out of domain keys can't be excluded
+	assert.True(t, policy.KeyMayMatch([]byte("other"), filter))
+}
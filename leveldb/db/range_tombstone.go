@@ -0,0 +1,124 @@
+package db
+
+// rangeTombstoneFragment covers [start, end), start being its key in rangeTombstoneFragments.
+type rangeTombstoneFragment struct {
+	end  Slice
+	seqs *sequenceList // 覆盖该区间的所有 range tombstone 的 sequence number
+}
+
+// sequenceList A persistent list of sequence numbers in descending order. Nodes are never
+// modified, so fragments share the tail of each other's lists instead of copying them.
+type sequenceList struct {
+	seq  SequenceNumber
+	next *sequenceList
+}
+
+// rangeTombstoneFragments Range tombstones split into non-overlapping fragments, so a point
+// lookup only needs the fragment found by SeekForPrev instead of every tombstone starting
+// before the key.
+//
+// Thread safety
+// -------------
+//
+// Same as SkipListMap: add requires external synchronization, maxCoveringSeq does not.
+// Fragments are never modified in place, add replaces them with Put. When a fragment is
+// split, the right half is added before the left half is shrunk, so a reader always finds a
+// fragment with the complete seqs for its key.
+type rangeTombstoneFragments struct {
+	cmp       func(a, b Slice) int
+	fragments *SkipListMap[Slice, rangeTombstoneFragment]
+}
+
+func newRangeTombstoneFragments(comparator *UserKeyComparator[Slice]) *rangeTombstoneFragments {
+	cmp := func(a, b Slice) int {
+		return comparator.Compare(&a, &b)
+	}
+	return &rangeTombstoneFragments{
+		cmp:       cmp,
+		fragments: NewSkipListMap[Slice, rangeTombstoneFragment](cmp),
+	}
+}
+
+// add Records a tombstone for [start, end) at seq. start and end must not be modified afterwards.
+// REQUIRES: start < end
+func (f *rangeTombstoneFragments) add(start, end Slice, seq SequenceNumber) {
+	f.split(start)
+	f.split(end)
+
+	iter := NewSkipListMapIterator(f.fragments)
+	for pos := start; f.cmp(pos, end) < 0; {
+		iter.Seek(pos)
+		if iter.Valid() && f.cmp(iter.Key(), pos) == 0 {
+			// 已经在 end 处切分过，fragment 一定在 [start, end) 内
+			fragment := iter.Value()
+			f.fragments.Put(pos, rangeTombstoneFragment{
+				end:  fragment.end,
+				seqs: insertSequence(fragment.seqs, seq),
+			})
+			pos = fragment.end
+			continue
+		}
+
+		// pos 之后没有 tombstone 覆盖，填补到下一个 fragment 或 end 为止
+		gapEnd := end
+		if iter.Valid() && f.cmp(iter.Key(), end) < 0 {
+			gapEnd = iter.Key()
+		}
+		f.fragments.Put(pos, rangeTombstoneFragment{
+			end:  gapEnd,
+			seqs: &sequenceList{seq: seq},
+		})
+		pos = gapEnd
+	}
+}
+
+// split Makes key a fragment boundary if it falls strictly inside a fragment.
+func (f *rangeTombstoneFragments) split(key Slice) {
+	iter := NewSkipListMapIterator(f.fragments)
+	iter.SeekForPrev(key)
+	if !iter.Valid() || f.cmp(iter.Key(), key) == 0 {
+		return
+	}
+	fragment := iter.Value()
+	if f.cmp(key, fragment.end) >= 0 {
+		return
+	}
+	f.fragments.Put(key, fragment)
+	f.fragments.Put(iter.Key(), rangeTombstoneFragment{
+		end:  key,
+		seqs: fragment.seqs,
+	})
+}
+
+// maxCoveringSeq Returns the largest sequence number <= lookupSeq of the tombstones covering key,
+// found is false if there is none.
+func (f *rangeTombstoneFragments) maxCoveringSeq(key Slice, lookupSeq SequenceNumber) (seq SequenceNumber, found bool) {
+	iter := NewSkipListMapIterator(f.fragments)
+	iter.SeekForPrev(key)
+	if !iter.Valid() {
+		return 0, false
+	}
+	fragment := iter.Value()
+	if f.cmp(key, fragment.end) >= 0 {
+		return 0, false
+	}
+	for list := fragment.seqs; list != nil; list = list.next {
+		if list.seq <= lookupSeq {
+			return list.seq, true
+		}
+	}
+	return 0, false
+}
+
+// insertSequence Returns list with seq added, list itself is unchanged since readers may be using it.
+// Sequence numbers usually grow, so seq normally goes on top and the whole list is shared;
+// otherwise only the nodes before seq are copied.
+func insertSequence(list *sequenceList, seq SequenceNumber) *sequenceList {
+	if list == nil || seq > list.seq {
+		return &sequenceList{seq: seq, next: list}
+	}
+	if seq == list.seq {
+		return list
+	}
+	return &sequenceList{seq: list.seq, next: insertSequence(list.next, seq)}
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRangeTombstoneFragments(t *testing.T) {
+	type tombstone struct {
+		start, end int
+		seq        SequenceNumber
+	}
+	key := func(i int) Slice {
+		return Slice(fmt.Sprintf("%03d", i))
+	}
+
+	fragments := newRangeTombstoneFragments(NewUserKeyComparator[Slice]())
+	_, found := fragments.maxCoveringSeq(key(0), 100)
+	assert.False(t, found)
+
+	rnd := rand.New(rand.NewSource(301))
+	tombstones := make([]tombstone, 0)
+	for i := 0; i < 200; i++ {
+		start := rnd.Intn(100)
+		end := start + 1 + rnd.Intn(20)
+		seq := SequenceNumber(rnd.Intn(1000))
+		fragments.add(key(start), key(end), seq)
+		tombstones = append(tombstones, tombstone{start, end, seq})
+
+		// Compare with the max over all tombstones
+		for k := 0; k < 125; k++ {
+			lookupSeq := SequenceNumber(rnd.Intn(1100))
+			expected, expectedFound := SequenceNumber(0), false
+			for _, ts := range tombstones {
+				if ts.start <= k && k < ts.end && ts.seq <= lookupSeq && (!expectedFound || ts.seq > expected) {
+					expected, expectedFound = ts.seq, true
+				}
+			}
+			seq, found := fragments.maxCoveringSeq(key(k), lookupSeq)
+			if !assert.Equal(t, expectedFound, found, "key %d seq %d", k, lookupSeq) ||
+				!assert.Equal(t, expected, seq, "key %d seq %d", k, lookupSeq) {
+				return
+			}
+		}
+	}
+
+	// Fragments don't overlap
+	iter := NewSkipListMapIterator(fragments.fragments)
+	var prevEnd Slice
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		if prevEnd != nil {
+			assert.LessOrEqual(t, string(prevEnd), string(iter.Key()))
+		}
+		assert.Less(t, string(iter.Key()), string(iter.Value().end))
+		prevEnd = iter.Value().end
+	}
+}
@@ -1,42 +1,93 @@
 package db
 
 import (
-	"math/rand"
 	"sync/atomic"
-	"time"
 	"unsafe"
+
+	"leveldb-golang/leveldb/util"
 )
 
 const (
-	skipListMaxHeight int32 = 12
+	skipListDefaultSeed      uint32 = 0xdeadbeef
+	skipListDefaultBranching uint32 = 4
+	skipListDefaultMaxHeight int32  = 12
 )
 
+// SkipListOptions 的零值表示使用默认值
+type SkipListOptions struct {
+	// Seed of the per-list random generator deciding node heights, lists built
+	// with the same seed and the same inserts have the same shape. 0 means the default seed.
+	Seed uint32
+	// Branching A node of height h is promoted to h+1 with probability 1/Branching.
+	// Larger values save memory, smaller values make searches shorter.
+	Branching uint32
+	// MaxHeight Upper bound of node heights.
+	MaxHeight int32
+}
+
 /*
 	1. head的key是nil，不能使用head的key
 	2. 如果node为nil，则认为这个node包含最大的key，即node为右边界
 */
 
-type SkipList[T KeyTypeSet] struct {
-	rnd            rand.Source
+type SkipList[T any] struct {
+	rnd            *util.Random // 只在 Insert 中使用，由写者独占
+	branching      uint32
+	maxHeight      int32
 	cmp            Comparator[T]
 	head           *SkipListNode[T]
 	_currentHeight int32
 }
 
-func NewSkipList[T KeyTypeSet](comparator Comparator[T]) *SkipList[T] {
+func NewSkipList[T any](comparator Comparator[T]) *SkipList[T] {
+	return NewSkipListWithOptions[T](comparator, SkipListOptions{})
+}
+
+func NewSkipListWithOptions[T any](comparator Comparator[T], options SkipListOptions) *SkipList[T] {
+	if options.Seed == 0 {
+		options.Seed = skipListDefaultSeed
+	}
+	if options.Branching < 2 {
+		options.Branching = skipListDefaultBranching
+	}
+	if options.MaxHeight <= 0 {
+		options.MaxHeight = skipListDefaultMaxHeight
+	}
 	return &SkipList[T]{
-		rnd:            rand.NewSource(time.Now().UnixNano()),
+		rnd:            util.NewRandom(options.Seed),
+		branching:      options.Branching,
+		maxHeight:      options.MaxHeight,
 		cmp:            comparator,
-		head:           NewSkipListNode[T](skipListMaxHeight, nil),
+		head:           NewSkipListNode[T](options.MaxHeight, nil),
 		_currentHeight: 1,
 	}
 }
 
-// Insert
-// REQUIRES: nothing that compares equal to key is currently in the list.
-func (s *SkipList[T]) Insert(key *T) {
-	prevNodes := make([]*SkipListNode[T], skipListMaxHeight, skipListMaxHeight)
-	_ = s.findGreaterOrEqual(key, prevNodes)
+// Insert Returns true iff an entry that compares equal to key already existed,
+// in which case the list is left unchanged. Callers are expected to never insert
+// duplicates, so in debug builds (-tags leveldb_debug) a duplicate panics.
+// Use InsertOrGet when duplicates are expected.
+func (s *SkipList[T]) Insert(key *T) bool {
+	_, existed := s.insert(key)
+	if existed && debugMode {
+		panic("skiplist: inserting a key that compares equal to an existing one")
+	}
+	return existed
+}
+
+// InsertOrGet If an entry that compares equal to key is in the list, returns (that entry, true).
+// Else inserts key and returns (key, false).
+func (s *SkipList[T]) InsertOrGet(key *T) (*T, bool) {
+	node, existed := s.insert(key)
+	return node.key, existed
+}
+
+func (s *SkipList[T]) insert(key *T) (*SkipListNode[T], bool) {
+	prevNodes := make([]*SkipListNode[T], s.maxHeight, s.maxHeight)
+	x := s.findGreaterOrEqual(key, prevNodes)
+	if x != nil && s.cmp.Compare(x.key, key) == 0 {
+		return x, true
+	}
 
 	height := s.randomHeight()
 	newNode := NewSkipListNode(height, key)
@@ -47,10 +98,17 @@ func (s *SkipList[T]) Insert(key *T) {
 		}
 		s.setCurrentHeight(height)
 	}
+	// prev 指针只在 level 0 维护，在 newNode 发布之前设置好，
+	// 读者读到旧的 succ.prev 时会跳过 newNode，这和 newNode 尚未插入时看到的结果一致
+	newNode.SetPrev(prevNodes[0])
 	for i := int32(0); i < height; i++ {
 		newNode.SetNext(i, prevNodes[i].Next(i))
 		prevNodes[i].SetNext(i, newNode)
 	}
+	if succ := newNode.Next(0); succ != nil {
+		succ.SetPrev(newNode)
+	}
+	return newNode, false
 }
 
 // Contains returns true iff an entry that compares equal to key is in the list.
@@ -127,21 +185,21 @@ func (s *SkipList[T]) setCurrentHeight(height int32) {
 }
 
 func (s *SkipList[T]) randomHeight() int32 {
-	const skipListBranching = 4
+	// Increase height with probability 1 in branching
 	height := int32(1)
-	for height < skipListMaxHeight && s.rnd.Int63()%skipListBranching == 0 {
+	for height < s.maxHeight && s.rnd.OneIn(s.branching) {
 		height += 1
 	}
 	return height
 }
 
 // SkipListIterator Iteration over the contents of a skip list
-type SkipListIterator[T KeyTypeSet] struct {
+type SkipListIterator[T any] struct {
 	list *SkipList[T]
 	node *SkipListNode[T]
 }
 
-func NewSkipListIterator[T KeyTypeSet](list *SkipList[T]) *SkipListIterator[T] {
+func NewSkipListIterator[T any](list *SkipList[T]) *SkipListIterator[T] {
 	return &SkipListIterator[T]{
 		list: list,
 		node: nil,
@@ -168,9 +226,7 @@ func (iter *SkipListIterator[T]) Next() {
 // Prev Advances to the previous position.
 // REQUIRES: Valid()
 func (iter *SkipListIterator[T]) Prev() {
-	// Instead of using explicit "prev" links, we just search for the
-	// last node that falls before key.
-	iter.node = iter.list.findLessThan(iter.node.key)
+	iter.node = iter.node.Prev()
 	if iter.node == iter.list.head {
 		iter.node = nil
 	}
@@ -181,6 +237,17 @@ func (iter *SkipListIterator[T]) Seek(target *T) {
 	iter.node = iter.list.findGreaterOrEqual(target, nil)
 }
 
+// SeekForPrev Advance to the last entry with a key <= target
+func (iter *SkipListIterator[T]) SeekForPrev(target *T) {
+	iter.node = iter.list.findLessThan(target)
+	if next := iter.node.Next(0); next != nil && iter.list.cmp.Compare(next.key, target) == 0 {
+		iter.node = next
+	}
+	if iter.node == iter.list.head {
+		iter.node = nil
+	}
+}
+
 // SeekToFirst Position at the first entry in list.
 // Final state of iterator is Valid() iff list is not empty.
 func (iter *SkipListIterator[T]) SeekToFirst() {
@@ -196,12 +263,13 @@ func (iter *SkipListIterator[T]) SeekToLast() {
 	}
 }
 
-type SkipListNode[T KeyTypeSet] struct {
+type SkipListNode[T any] struct {
 	_next []*SkipListNode[T]
+	_prev *SkipListNode[T] // level 0 的前驱节点，第一个节点的前驱为 head
 	key   *T
 }
 
-func NewSkipListNode[T KeyTypeSet](height int32, key *T) *SkipListNode[T] {
+func NewSkipListNode[T any](height int32, key *T) *SkipListNode[T] {
 	return &SkipListNode[T]{
 		_next: make([]*SkipListNode[T], height, height),
 		key:   key,
@@ -215,3 +283,11 @@ func (node *SkipListNode[T]) Next(n int32) *SkipListNode[T] {
 func (node *SkipListNode[T]) SetNext(n int32, nextNode *SkipListNode[T]) {
 	atomic.StorePointer((*unsafe.Pointer)(unsafe.Pointer(&node._next[n])), unsafe.Pointer(nextNode))
 }
+
+func (node *SkipListNode[T]) Prev() *SkipListNode[T] {
+	return (*SkipListNode[T])(atomic.LoadPointer((*unsafe.Pointer)(unsafe.Pointer(&node._prev))))
+}
+
+func (node *SkipListNode[T]) SetPrev(prevNode *SkipListNode[T]) {
+	atomic.StorePointer((*unsafe.Pointer)(unsafe.Pointer(&node._prev)), unsafe.Pointer(prevNode))
+}
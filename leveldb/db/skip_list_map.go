@@ -0,0 +1,183 @@
+package db
+
+import (
+	"sync/atomic"
+)
+
+type skipListMapEntry[K, V any] struct {
+	key   K
+	value atomic.Pointer[V] // nil 表示该 key 已经被删除（tombstone）
+}
+
+type skipListMapComparator[K, V any] struct {
+	cmp func(a, b K) int
+}
+
+func (c *skipListMapComparator[K, V]) Compare(a, b *skipListMapEntry[K, V]) int {
+	return c.cmp(a.key, b.key)
+}
+
+func (*skipListMapComparator[K, V]) Name() string {
+	return "leveldb.skipListMapComparator"
+}
+
+// SkipListMap An ordered map built on SkipList.
+//
+// Thread safety
+// -------------
+//
+// Writes (Put, Delete) require external synchronization, most likely a mutex.
+// Reads (Get, Len, iteration) require no synchronization and may run
+// concurrently with a writer, same as SkipList.
+//
+// Delete only marks the entry as a tombstone: nodes are never unlinked, since
+// readers may be positioned on them. A later Put of the same key reuses the node.
+type SkipListMap[K, V any] struct {
+	list   *SkipList[skipListMapEntry[K, V]]
+	length atomic.Int64
+}
+
+// NewSkipListMap cmp < 0 iff "a" < "b", == 0 iff "a" == "b", > 0 iff "a" > "b"
+func NewSkipListMap[K, V any](cmp func(a, b K) int) *SkipListMap[K, V] {
+	return NewSkipListMapWithOptions[K, V](cmp, SkipListOptions{})
+}
+
+func NewSkipListMapWithOptions[K, V any](cmp func(a, b K) int, options SkipListOptions) *SkipListMap[K, V] {
+	return &SkipListMap[K, V]{
+		list: NewSkipListWithOptions[skipListMapEntry[K, V]](&skipListMapComparator[K, V]{cmp: cmp}, options),
+	}
+}
+
+// Put Sets the value of key, replacing the existing one if any.
+func (m *SkipListMap[K, V]) Put(key K, value V) {
+	newEntry := &skipListMapEntry[K, V]{key: key}
+	newEntry.value.Store(&value)
+	if entry, existed := m.list.InsertOrGet(newEntry); existed {
+		if entry.value.Swap(&value) != nil {
+			return
+		}
+	}
+	m.length.Add(1)
+}
+
+// Get Returns the value of key, and whether key is present.
+func (m *SkipListMap[K, V]) Get(key K) (V, bool) {
+	if entry := m.find(key); entry != nil {
+		if value := entry.value.Load(); value != nil {
+			return *value, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// Delete Removes key, returns true iff key was present.
+func (m *SkipListMap[K, V]) Delete(key K) bool {
+	entry := m.find(key)
+	if entry == nil || entry.value.Swap(nil) == nil {
+		return false
+	}
+	m.length.Add(-1)
+	return true
+}
+
+// Len Returns the number of keys present, tombstones excluded.
+func (m *SkipListMap[K, V]) Len() int {
+	return int(m.length.Load())
+}
+
+// find 返回 key 对应的 entry（可能是 tombstone），不存在时返回 nil
+func (m *SkipListMap[K, V]) find(key K) *skipListMapEntry[K, V] {
+	target := &skipListMapEntry[K, V]{key: key}
+	x := m.list.findGreaterOrEqual(target, nil)
+	if x != nil && m.list.cmp.Compare(x.key, target) == 0 {
+		return x.key
+	}
+	return nil
+}
+
+// SkipListMapIterator Iteration over the live entries of a SkipListMap, tombstones are skipped.
+type SkipListMapIterator[K, V any] struct {
+	iter  *SkipListIterator[skipListMapEntry[K, V]]
+	value *V
+}
+
+func NewSkipListMapIterator[K, V any](m *SkipListMap[K, V]) *SkipListMapIterator[K, V] {
+	return &SkipListMapIterator[K, V]{
+		iter: NewSkipListIterator(m.list),
+	}
+}
+
+// Valid Returns true iff the iterator is positioned at a live entry.
+func (iter *SkipListMapIterator[K, V]) Valid() bool {
+	return iter.iter.Valid()
+}
+
+// Key Returns the key at the current position.
+// REQUIRES: Valid()
+func (iter *SkipListMapIterator[K, V]) Key() K {
+	return iter.iter.GetKey().key
+}
+
+// Value Returns the value at the current position, as of when the iterator moved there.
+// REQUIRES: Valid()
+func (iter *SkipListMapIterator[K, V]) Value() V {
+	return *iter.value
+}
+
+// Next Advances to the next live entry.
+// REQUIRES: Valid()
+func (iter *SkipListMapIterator[K, V]) Next() {
+	iter.iter.Next()
+	iter.skipForward()
+}
+
+// Prev Advances to the previous live entry.
+// REQUIRES: Valid()
+func (iter *SkipListMapIterator[K, V]) Prev() {
+	iter.iter.Prev()
+	iter.skipBackward()
+}
+
+// Seek Advance to the first live entry with a key >= target
+func (iter *SkipListMapIterator[K, V]) Seek(target K) {
+	iter.iter.Seek(&skipListMapEntry[K, V]{key: target})
+	iter.skipForward()
+}
+
+// SeekForPrev Advance to the last live entry with a key <= target
+func (iter *SkipListMapIterator[K, V]) SeekForPrev(target K) {
+	iter.iter.SeekForPrev(&skipListMapEntry[K, V]{key: target})
+	iter.skipBackward()
+}
+
+// SeekToFirst Position at the first live entry in map.
+func (iter *SkipListMapIterator[K, V]) SeekToFirst() {
+	iter.iter.SeekToFirst()
+	iter.skipForward()
+}
+
+// SeekToLast Position at the last live entry in map.
+func (iter *SkipListMapIterator[K, V]) SeekToLast() {
+	iter.iter.SeekToLast()
+	iter.skipBackward()
+}
+
+// skipForward 跳过 tombstone，value 在这里读取一次，保证 Valid() 时 Value() 不会读到 nil
+func (iter *SkipListMapIterator[K, V]) skipForward() {
+	for iter.iter.Valid() {
+		if iter.value = iter.iter.GetKey().value.Load(); iter.value != nil {
+			return
+		}
+		iter.iter.Next()
+	}
+}
+
+func (iter *SkipListMapIterator[K, V]) skipBackward() {
+	for iter.iter.Valid() {
+		if iter.value = iter.iter.GetKey().value.Load(); iter.value != nil {
+			return
+		}
+		iter.iter.Prev()
+	}
+}
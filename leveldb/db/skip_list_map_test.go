@@ -0,0 +1,146 @@
+package db
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipListMapPutGetDelete(t *testing.T) {
+	m := NewSkipListMap[string, int](strings.Compare)
+	assert.Equal(t, 0, m.Len())
+
+	_, ok := m.Get("foo")
+	assert.False(t, ok)
+	assert.False(t, m.Delete("foo"))
+
+	m.Put("foo", 1)
+	m.Put("bar", 2)
+	assert.Equal(t, 2, m.Len())
+	value, ok := m.Get("foo")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	m.Put("foo", 3)
+	assert.Equal(t, 2, m.Len())
+	value, _ = m.Get("foo")
+	assert.Equal(t, 3, value)
+
+	assert.True(t, m.Delete("foo"))
+	assert.False(t, m.Delete("foo"))
+	assert.Equal(t, 1, m.Len())
+	_, ok = m.Get("foo")
+	assert.False(t, ok)
+
+	// Put revives a deleted key
+	m.Put("foo", 4)
+	assert.Equal(t, 2, m.Len())
+	value, ok = m.Get("foo")
+	assert.True(t, ok)
+	assert.Equal(t, 4, value)
+}
+
+func TestSkipListMapIterator(t *testing.T) {
+	type point struct{ x, y int }
+	// Keys of an arbitrary type, ordered by x then y
+	m := NewSkipListMap[point, string](func(a, b point) int {
+		if a.x != b.x {
+			return a.x - b.x
+		}
+		return a.y - b.y
+	})
+
+	iter := NewSkipListMapIterator(m)
+	iter.SeekToFirst()
+	assert.False(t, iter.Valid())
+
+	rnd := rand.New(rand.NewSource(301))
+	expected := make(map[point]string)
+	for i := 0; i < 1000; i++ {
+		p := point{rnd.Intn(50), rnd.Intn(50)}
+		if rnd.Intn(4) == 0 {
+			m.Delete(p)
+			delete(expected, p)
+		} else {
+			m.Put(p, NumberToString(i))
+			expected[p] = NumberToString(i)
+		}
+	}
+	assert.Equal(t, len(expected), m.Len())
+
+	keys := make([]point, 0, len(expected))
+	for p := range expected {
+		keys = append(keys, p)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].x < keys[j].x || (keys[i].x == keys[j].x && keys[i].y < keys[j].y)
+	})
+
+	// Forward iteration skips tombstones
+	idx := 0
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		assert.Equal(t, keys[idx], iter.Key())
+		assert.Equal(t, expected[keys[idx]], iter.Value())
+		idx++
+	}
+	assert.Equal(t, len(keys), idx)
+
+	// Backward iteration skips tombstones
+	idx = len(keys) - 1
+	for iter.SeekToLast(); iter.Valid(); iter.Prev() {
+		assert.Equal(t, keys[idx], iter.Key())
+		idx--
+	}
+	assert.Equal(t, -1, idx)
+
+	// Range iteration over [{10, 0}, {20, 0})
+	count := 0
+	for iter.Seek(point{10, 0}); iter.Valid() && iter.Key().x < 20; iter.Next() {
+		assert.GreaterOrEqual(t, iter.Key().x, 10)
+		count++
+	}
+	expectedCount := 0
+	for _, p := range keys {
+		if p.x >= 10 && p.x < 20 {
+			expectedCount++
+		}
+	}
+	assert.Equal(t, expectedCount, count)
+
+	iter.SeekForPrev(point{100, 0})
+	assert.True(t, iter.Valid())
+	assert.Equal(t, keys[len(keys)-1], iter.Key())
+}
+
+func TestSkipListMapConcurrentRead(t *testing.T) {
+	const N = 10000
+	m := NewSkipListMap[uint64, uint64](func(a, b uint64) int {
+		return (&_IntComparator[uint64]{}).Compare(&a, &b)
+	})
+
+	var written uint64
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for atomic.LoadUint64(&written) < N {
+			n := atomic.LoadUint64(&written)
+			// Everything written before the read started must be visible
+			for k := uint64(0); k < n; k++ {
+				value, ok := m.Get(k)
+				if !assert.True(t, ok) || !assert.Equal(t, k*2, value) {
+					return
+				}
+			}
+		}
+	}()
+
+	for k := uint64(0); k < N; k++ {
+		m.Put(k, k*2)
+		atomic.StoreUint64(&written, k+1)
+	}
+	<-done
+}
@@ -29,6 +29,9 @@ func TestEmptySkipList(t *testing.T) {
 
 	iter.SeekToLast()
 	assert.Equal(t, false, iter.Valid())
+
+	iter.SeekForPrev(&key)
+	assert.Equal(t, false, iter.Valid())
 }
 
 func TestInsertAndLookup(t *testing.T) {
@@ -109,6 +112,122 @@ func TestInsertAndLookup(t *testing.T) {
 	assert.Equal(t, false, iter.Valid())
 }
 
+func TestInsertDuplicate(t *testing.T) {
+	list := NewSkipList[uint64](&_IntComparator[uint64]{})
+	key, duplicate := uint64(10), uint64(10)
+	assert.False(t, list.Insert(&key))
+	if !debugMode {
+		assert.True(t, list.Insert(&duplicate))
+	} else {
+		assert.Panics(t, func() { list.Insert(&duplicate) })
+	}
+
+	existing, existed := list.InsertOrGet(&duplicate)
+	assert.True(t, existed)
+	assert.Same(t, &key, existing)
+
+	other := uint64(20)
+	inserted, existed := list.InsertOrGet(&other)
+	assert.False(t, existed)
+	assert.Same(t, &other, inserted)
+
+	// No duplicate nodes were created
+	iter := NewSkipListIterator(list)
+	iter.SeekToFirst()
+	assert.Same(t, &key, iter.GetKey())
+	iter.Next()
+	assert.Same(t, &other, iter.GetKey())
+	iter.Next()
+	assert.False(t, iter.Valid())
+}
+
+func TestSeekForPrev(t *testing.T) {
+	list := NewSkipList[uint64](&_IntComparator[uint64]{})
+	for _, k := range []uint64{10, 20, 30} {
+		key := k
+		list.Insert(&key)
+	}
+
+	iter := NewSkipListIterator(list)
+	cases := []struct {
+		target uint64
+		valid  bool
+		key    uint64
+	}{
+		{5, false, 0},
+		{10, true, 10},
+		{15, true, 10},
+		{20, true, 20},
+		{29, true, 20},
+		{30, true, 30},
+		{100, true, 30},
+	}
+	for _, c := range cases {
+		target := c.target
+		iter.SeekForPrev(&target)
+		assert.Equalf(t, c.valid, iter.Valid(), "target = %d", target)
+		if c.valid {
+			assert.Equalf(t, c.key, *iter.GetKey(), "target = %d", target)
+		}
+	}
+
+	// Iteration continues in both directions from the found entry
+	target := uint64(25)
+	iter.SeekForPrev(&target)
+	iter.Prev()
+	assert.Equal(t, uint64(10), *iter.GetKey())
+	iter.SeekForPrev(&target)
+	iter.Next()
+	assert.Equal(t, uint64(30), *iter.GetKey())
+}
+
+func nodeHeights(list *SkipList[uint64]) []int {
+	heights := make([]int, 0)
+	for node := list.head.Next(0); node != nil; node = node.Next(0) {
+		heights = append(heights, len(node._next))
+	}
+	return heights
+}
+
+func TestSkipListOptions(t *testing.T) {
+	build := func(options SkipListOptions) *SkipList[uint64] {
+		list := NewSkipListWithOptions[uint64](&_IntComparator[uint64]{}, options)
+		rnd := rand.NewSource(301)
+		for i := 0; i < 1000; i++ {
+			key := uint64(rnd.Int63())
+			if !list.Contains(&key) {
+				list.Insert(&key)
+			}
+		}
+		return list
+	}
+
+	// Same seed, same shape
+	assert.Equal(t, nodeHeights(build(SkipListOptions{Seed: 7})), nodeHeights(build(SkipListOptions{Seed: 7})))
+	assert.NotEqual(t, nodeHeights(build(SkipListOptions{Seed: 7})), nodeHeights(build(SkipListOptions{Seed: 8})))
+	assert.Equal(t, nodeHeights(build(SkipListOptions{})), nodeHeights(build(SkipListOptions{})))
+
+	list := build(SkipListOptions{Branching: 2, MaxHeight: 4})
+	maxHeight := 0
+	for _, height := range nodeHeights(list) {
+		maxHeight = max(maxHeight, height)
+	}
+	assert.Equal(t, 4, maxHeight)
+	assert.Len(t, list.head._next, 4)
+
+	iter := NewSkipListIterator(list)
+	count := 0
+	var last uint64
+	for iter.SeekToFirst(); iter.Valid(); iter.Next() {
+		if count > 0 {
+			assert.Less(t, last, *iter.GetKey())
+		}
+		last = *iter.GetKey()
+		count++
+	}
+	assert.Equal(t, 1000, count)
+}
+
 // We want to make sure that with a single writer and multiple
 // concurrent readers (with no synchronization other than when a
 // reader's iterator is created), the reader always observes all the
@@ -130,9 +249,10 @@ func TestInsertAndLookup(t *testing.T) {
 //
 // At the beginning of a read, we snapshot the last inserted
 // generation number for each key.  We then iterate, including random
-// calls to Next() and Seek().  For every key we encounter, we
+// calls to Next(), Prev() and Seek().  For every key we encounter, we
 // check that it is either expected given the initial snapshot or has
-// been concurrently added since the iterator started.
+// been concurrently added since the iterator started.  Prev() must move
+// to a smaller key without skipping any key of the initial snapshot.
 func TestWithoutConcurrent(t *testing.T) {
 	ct := NewConcurrentTest(t)
 	randSource := rand.NewSource(0)
@@ -305,17 +425,57 @@ func (ct *ConcurrentTest) readStep(src rand.Source) {
 			break
 		}
 
-		if src.Int63()%2 == 0 {
+		switch src.Int63() % 4 {
+		case 0, 1:
 			iterator.Next()
 			pos = ct.makeKey(ct.key(pos), ct.gen(pos)+1)
-		} else {
+		case 2:
 			newPos := ct.randomTarget(src)
 			if newPos > pos {
 				pos = newPos
 				iterator.Seek(&newPos)
 			}
+		case 3:
+			iterator.Prev()
+			if !iterator.Valid() {
+				// Nothing from initialState may be before current
+				assert.False(ct.t, ct.initialKeyBetween(nil, current, initialState))
+				return
+			}
+			prev := *iterator.GetKey()
+			assert.True(ct.t, ct.isValidKey(prev))
+			assert.Less(ct.t, prev, current)
+			assert.False(ct.t, ct.initialKeyBetween(&prev, current, initialState))
+			pos = prev
+		}
+	}
+}
+
+// initialKeyBetween Returns true iff a key of initialState is in (lo, hi), lo == nil meaning no lower bound.
+func (ct *ConcurrentTest) initialKeyBetween(lo *uint64, hi uint64, initialState *GenerationState) bool {
+	// key 的顺序与 (key, gen) 的顺序一致，initialState 中 key k 的 gen 为 [1, initialState.Get(k)]
+	for k := uint64(0); k < K; k++ {
+		minGen, maxGen := uint64(1), initialState.Get(k)
+		if lo != nil {
+			if k < ct.key(*lo) {
+				continue
+			} else if k == ct.key(*lo) {
+				minGen = max(minGen, ct.gen(*lo)+1)
+			}
+		}
+		if k > ct.key(hi) {
+			continue
+		} else if k == ct.key(hi) {
+			if ct.gen(hi) == 0 {
+				continue
+			}
+			maxGen = min(maxGen, ct.gen(hi)-1)
+		}
+		if minGen <= maxGen {
+			return true
 		}
 	}
+	return false
 }
 
 type GenerationState struct {
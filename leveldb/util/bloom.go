@@ -0,0 +1,123 @@
+package util
+
+// FilterPolicy A database can be configured with a custom FilterPolicy object.
+// This object is responsible for creating a small filter from a set
+// of keys. These filters are stored in leveldb and are consulted
+// automatically by leveldb to decide whether or not to read some
+// information from disk. In many cases, a filter can cut down the
+// number of disk seeks form a handful to a single disk seek per
+// DB::Get() call.
+type FilterPolicy interface {
+	// Name Return the name of this policy. Note that if the filter encoding
+	// changes in an incompatible way, the name returned by this method
+	// must be changed. Otherwise, old incompatible filters may be
+	// passed to methods of this type.
+	Name() string
+
+	// CreateFilter keys contains a list of keys (potentially with duplicates)
+	// that are ordered according to the user supplied comparator.
+	// Append a filter that summarizes keys to dst and return the result.
+	CreateFilter(keys [][]byte, dst []byte) []byte
+
+	// KeyMayMatch "filter" contains the data appended by a preceding call to
+	// CreateFilter() on this type. This method must return true if
+	// the key was in the list of keys passed to CreateFilter().
+	// This method may return true or false if the key was not on the
+	// list, but it should aim to return false with a high probability.
+	KeyMayMatch(key []byte, filter []byte) bool
+}
+
+type bloomFilterPolicy struct {
+	bitsPerKey uint32
+	k          uint32
+}
+
+// NewBloomFilterPolicy Return a new filter policy that uses a bloom filter with approximately
+// the specified number of bits per key. A good value for bitsPerKey
+// is 10, which yields a filter with ~ 1% false positive rate.
+func NewBloomFilterPolicy(bitsPerKey uint32) FilterPolicy {
+	// We intentionally round down to reduce probing cost a little bit
+	k := uint32(float64(bitsPerKey) * 0.69) // 0.69 =~ ln(2)
+	if k < 1 {
+		k = 1
+	}
+	if k > 30 {
+		k = 30
+	}
+	return &bloomFilterPolicy{
+		bitsPerKey: bitsPerKey,
+		k:          k,
+	}
+}
+
+func bloomHash(key []byte) uint32 {
+	return Hash(key, 0xbc9f1d34)
+}
+
+func (*bloomFilterPolicy) Name() string {
+	// 使用 murmur3 和 enhanced double-hashing，与 LevelDB 的 "leveldb.BuiltinBloomFilter2" 不兼容
+	return "leveldb.BuiltinBloomFilterMurmur3"
+}
+
+func (p *bloomFilterPolicy) CreateFilter(keys [][]byte, dst []byte) []byte {
+	// Compute bloom filter size (in both bits and bytes)
+	bits := uint32(len(keys)) * p.bitsPerKey
+
+	// For small n, we can see a very high false positive rate. Fix it
+	// by enforcing a minimum bloom filter length.
+	if bits < 64 {
+		bits = 64
+	}
+
+	bytes := (bits + 7) / 8
+	bits = bytes * 8
+
+	initSize := len(dst)
+	dst = append(dst, make([]byte, bytes)...)
+	dst = append(dst, uint8(p.k)) // Remember # of probes in filter
+	array := dst[initSize:]
+	for _, key := range keys {
+		// Use enhanced double-hashing to generate a sequence of hash values.
+		// See analysis in [Kirsch,Mitzenmacher 2006] and [Dillinger,Manolios 2004].
+		// Growing delta keeps the probes from cycling when bits is a power of two (e.g. the 64 bits minimum).
+		h := bloomHash(key)
+		delta := (h >> 17) | (h << 15) // Rotate right 17 bits
+		for j := uint32(0); j < p.k; j++ {
+			bitPos := h % bits
+			array[bitPos/8] |= 1 << (bitPos % 8)
+			h += delta
+			delta += j
+		}
+	}
+	return dst
+}
+
+func (p *bloomFilterPolicy) KeyMayMatch(key []byte, filter []byte) bool {
+	length := uint32(len(filter))
+	if length < 2 {
+		return false
+	}
+
+	bits := (length - 1) * 8
+
+	// Use the encoded k so that we can read filters generated by
+	// bloom filters created using different parameters.
+	k := uint32(filter[length-1])
+	if k > 30 {
+		// Reserved for potentially new encodings for short bloom filters.
+		// Consider it a match.
+		return true
+	}
+
+	h := bloomHash(key)
+	delta := (h >> 17) | (h << 15) // Rotate right 17 bits
+	for j := uint32(0); j < k; j++ {
+		bitPos := h % bits
+		if filter[bitPos/8]&(1<<(bitPos%8)) == 0 {
+			return false
+		}
+		h += delta
+		delta += j
+	}
+	return true
+}
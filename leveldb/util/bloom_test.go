@@ -0,0 +1,117 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type BloomTest struct {
+	policy FilterPolicy
+	filter []byte
+	keys   [][]byte
+}
+
+func NewBloomTest() *BloomTest {
+	return &BloomTest{
+		policy: NewBloomFilterPolicy(10),
+	}
+}
+
+func (bt *BloomTest) Reset() {
+	bt.keys = bt.keys[:0]
+	bt.filter = bt.filter[:0]
+}
+
+func (bt *BloomTest) Add(key []byte) {
+	bt.keys = append(bt.keys, key)
+}
+
+func (bt *BloomTest) Build() {
+	bt.filter = bt.policy.CreateFilter(bt.keys, bt.filter[:0])
+	bt.keys = bt.keys[:0]
+}
+
+func (bt *BloomTest) Matches(key []byte) bool {
+	if len(bt.keys) != 0 {
+		bt.Build()
+	}
+	return bt.policy.KeyMayMatch(key, bt.filter)
+}
+
+func (bt *BloomTest) FalsePositiveRate() float64 {
+	result := 0
+	for i := 0; i < 10000; i++ {
+		if bt.Matches(bloomKey(i + 1000000000)) {
+			result++
+		}
+	}
+	return float64(result) / 10000.0
+}
+
+func bloomKey(i int) []byte {
+	data := make([]byte, 4)
+	EncodeFixedUint32(data, uint32(i))
+	return data
+}
+
+func nextLength(length int) int {
+	if length < 10 {
+		length += 1
+	} else if length < 100 {
+		length += 10
+	} else if length < 1000 {
+		length += 100
+	} else {
+		length += 1000
+	}
+	return length
+}
+
+func TestEmptyFilter(t *testing.T) {
+	bt := NewBloomTest()
+	assert.False(t, bt.Matches([]byte("hello")))
+	assert.False(t, bt.Matches([]byte("world")))
+}
+
+func TestSmallFilter(t *testing.T) {
+	bt := NewBloomTest()
+	bt.Add([]byte("hello"))
+	bt.Add([]byte("world"))
+	assert.True(t, bt.Matches([]byte("hello")))
+	assert.True(t, bt.Matches([]byte("world")))
+	assert.False(t, bt.Matches([]byte("x")))
+	assert.False(t, bt.Matches([]byte("foo")))
+}
+
+func TestVaryingLengths(t *testing.T) {
+	bt := NewBloomTest()
+
+	// Count number of filters that significantly exceed the false positive rate
+	mediocreFilters, goodFilters := 0, 0
+
+	for length := 1; length <= 10000; length = nextLength(length) {
+		bt.Reset()
+		for i := 0; i < length; i++ {
+			bt.Add(bloomKey(i))
+		}
+		bt.Build()
+
+		assert.LessOrEqual(t, len(bt.filter), length*10/8+40)
+
+		// All added keys must match
+		for i := 0; i < length; i++ {
+			assert.Truef(t, bt.Matches(bloomKey(i)), "Length %d; key %d", length, i)
+		}
+
+		// Check false positive rate
+		rate := bt.FalsePositiveRate()
+		assert.LessOrEqual(t, rate, 0.02) // Must not be over 2%
+		if rate > 0.0125 {
+			mediocreFilters++ // Allowed, but not too often
+		} else {
+			goodFilters++
+		}
+	}
+	assert.LessOrEqual(t, mediocreFilters, goodFilters/5)
+}
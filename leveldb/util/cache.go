@@ -0,0 +1,298 @@
+package util
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Cache A Cache is an interface that maps keys to values. It has internal
+// synchronization and may be safely accessed concurrently from
+// multiple goroutines. It may automatically evict entries to make room
+// for new entries. Values have a specified charge against the cache
+// capacity. For example, a cache where the values are variable
+// length strings, may use the length of the string as the charge for
+// the string.
+type Cache interface {
+	// Insert a mapping from key->value into the cache and assign it
+	// the specified charge against the total cache capacity.
+	//
+	// Returns a handle that corresponds to the mapping. The caller
+	// must call Release(handle) when the returned mapping is no
+	// longer needed.
+	//
+	// When the inserted entry is no longer needed, the key and
+	// value will be passed to "deleter".
+	Insert(key []byte, value any, charge uint64, deleter func(key []byte, value any)) *CacheHandle
+
+	// Lookup If the cache has no mapping for "key", returns nil.
+	//
+	// Else return a handle that corresponds to the mapping. The caller
+	// must call Release(handle) when the returned mapping is no
+	// longer needed.
+	Lookup(key []byte) *CacheHandle
+
+	// Release a mapping returned by a previous Lookup().
+	// REQUIRES: handle must not have been released yet.
+	// REQUIRES: handle must have been returned by a method on *this.
+	Release(handle *CacheHandle)
+
+	// Value Return the value encapsulated in a handle returned by a
+	// successful Lookup().
+	// REQUIRES: handle must not have been released yet.
+	// REQUIRES: handle must have been returned by a method on *this.
+	Value(handle *CacheHandle) any
+
+	// Erase If the cache contains entry for key, erase it. Note that the
+	// underlying entry will be kept around until all existing handles
+	// to it have been released.
+	Erase(key []byte)
+
+	// NewId Return a new numeric id. May be used by multiple clients who are
+	// sharing the same cache to partition the key space. Typically the
+	// client will allocate a new id at startup and prepend the id to
+	// its cache keys.
+	NewId() uint64
+
+	// Prune Remove all cache entries that are not actively in use. Memory-constrained
+	// applications may wish to call this method to reduce memory usage.
+	Prune()
+
+	// TotalCharge Return an estimate of the combined charges of all elements stored in the
+	// cache.
+	TotalCharge() uint64
+}
+
+// CacheHandle An opaque handle to an entry stored in a Cache. Entries
+// are kept in a circular doubly linked list ordered by access time.
+type CacheHandle struct {
+	value   any
+	deleter func(key []byte, value any)
+	next    *CacheHandle
+	prev    *CacheHandle
+	charge  uint64
+	key     []byte
+	inCache bool   // Whether entry is in the cache.
+	refs    uint32 // References, including cache reference, if present.
+}
+
+// lruCache A single shard of sharded cache.
+type lruCache struct {
+	capacity uint64
+
+	mutex sync.Mutex
+	usage uint64
+
+	// Dummy head of LRU list.
+	// lru.prev is newest entry, lru.next is oldest entry.
+	// Entries have refs==1 and inCache==true.
+	lru CacheHandle
+
+	// Dummy head of in-use list.
+	// Entries are in use by clients, and have refs >= 2 and inCache==true.
+	inUse CacheHandle
+
+	table map[string]*CacheHandle
+}
+
+func newLRUCache(capacity uint64) *lruCache {
+	cache := &lruCache{
+		capacity: capacity,
+		table:    make(map[string]*CacheHandle),
+	}
+	// Make empty circular linked lists.
+	cache.lru.next = &cache.lru
+	cache.lru.prev = &cache.lru
+	cache.inUse.next = &cache.inUse
+	cache.inUse.prev = &cache.inUse
+	return cache
+}
+
+func (c *lruCache) ref(e *CacheHandle) {
+	if e.refs == 1 && e.inCache { // If on lru list, move to inUse list.
+		lruRemove(e)
+		lruAppend(&c.inUse, e)
+	}
+	e.refs++
+}
+
+func (c *lruCache) unref(e *CacheHandle) {
+	e.refs--
+	if e.refs == 0 { // Deallocate.
+		if e.deleter != nil {
+			e.deleter(e.key, e.value)
+		}
+	} else if e.inCache && e.refs == 1 {
+		// No longer in use; move to lru list.
+		lruRemove(e)
+		lruAppend(&c.lru, e)
+	}
+}
+
+func lruRemove(e *CacheHandle) {
+	e.next.prev = e.prev
+	e.prev.next = e.next
+}
+
+func lruAppend(list *CacheHandle, e *CacheHandle) {
+	// Make "e" newest entry by inserting just before *list
+	e.next = list
+	e.prev = list.prev
+	e.prev.next = e
+	e.next.prev = e
+}
+
+func (c *lruCache) lookup(key []byte) *CacheHandle {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.table[string(key)]
+	if !ok {
+		return nil
+	}
+	c.ref(e)
+	return e
+}
+
+func (c *lruCache) release(handle *CacheHandle) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.unref(handle)
+}
+
+func (c *lruCache) insert(key []byte, value any, charge uint64, deleter func(key []byte, value any)) *CacheHandle {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e := &CacheHandle{
+		value:   value,
+		deleter: deleter,
+		charge:  charge,
+		key:     append([]byte(nil), key...),
+		refs:    1, // for the returned handle.
+	}
+
+	if c.capacity > 0 {
+		e.refs++ // for the cache's reference.
+		e.inCache = true
+		lruAppend(&c.inUse, e)
+		c.usage += charge
+		c.finishErase(c.table[string(key)])
+		c.table[string(key)] = e
+	}
+	// else don't cache. (capacity==0 is supported and turns off caching.)
+
+	for c.usage > c.capacity && c.lru.next != &c.lru {
+		old := c.lru.next
+		delete(c.table, string(old.key))
+		c.finishErase(old)
+	}
+
+	return e
+}
+
+// finishErase If e != nil, finish removing *e from the cache; it has already been
+// removed from the hash table.
+func (c *lruCache) finishErase(e *CacheHandle) {
+	if e == nil {
+		return
+	}
+	lruRemove(e)
+	e.inCache = false
+	c.usage -= e.charge
+	c.unref(e)
+}
+
+func (c *lruCache) erase(key []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.table[string(key)]
+	if !ok {
+		return
+	}
+	delete(c.table, string(key))
+	c.finishErase(e)
+}
+
+func (c *lruCache) prune() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for c.lru.next != &c.lru {
+		e := c.lru.next
+		delete(c.table, string(e.key))
+		c.finishErase(e)
+	}
+}
+
+func (c *lruCache) totalCharge() uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.usage
+}
+
+const (
+	numShardBits = 4
+	numShards    = 1 << numShardBits
+)
+
+type shardedLRUCache struct {
+	shards [numShards]*lruCache
+	lastId uint64
+}
+
+// NewLRUCache Create a new cache with a fixed size capacity. This implementation
+// of Cache uses a least-recently-used eviction policy.
+func NewLRUCache(capacity uint64) Cache {
+	cache := &shardedLRUCache{}
+	perShard := (capacity + (numShards - 1)) / numShards
+	for i := range cache.shards {
+		cache.shards[i] = newLRUCache(perShard)
+	}
+	return cache
+}
+
+func shard(key []byte) uint32 {
+	return Hash(key, 0) >> (32 - numShardBits)
+}
+
+func (c *shardedLRUCache) Insert(key []byte, value any, charge uint64,
+	deleter func(key []byte, value any)) *CacheHandle {
+	return c.shards[shard(key)].insert(key, value, charge, deleter)
+}
+
+func (c *shardedLRUCache) Lookup(key []byte) *CacheHandle {
+	return c.shards[shard(key)].lookup(key)
+}
+
+func (c *shardedLRUCache) Release(handle *CacheHandle) {
+	c.shards[shard(handle.key)].release(handle)
+}
+
+func (c *shardedLRUCache) Value(handle *CacheHandle) any {
+	return handle.value
+}
+
+func (c *shardedLRUCache) Erase(key []byte) {
+	c.shards[shard(key)].erase(key)
+}
+
+func (c *shardedLRUCache) NewId() uint64 {
+	return atomic.AddUint64(&c.lastId, 1)
+}
+
+func (c *shardedLRUCache) Prune() {
+	for _, s := range c.shards {
+		s.prune()
+	}
+}
+
+func (c *shardedLRUCache) TotalCharge() uint64 {
+	total := uint64(0)
+	for _, s := range c.shards {
+		total += s.totalCharge()
+	}
+	return total
+}
@@ -0,0 +1,229 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const cacheSize = 1000
+
+func encodeKey(k int) []byte {
+	data := make([]byte, 4)
+	EncodeFixedUint32(data, uint32(k))
+	return data
+}
+
+func decodeKey(k []byte) int {
+	return int(DecodeFixedUint32(k))
+}
+
+type CacheTest struct {
+	cache       Cache
+	deletedKeys []int
+	deletedVals []int
+}
+
+func NewCacheTest() *CacheTest {
+	return &CacheTest{
+		cache: NewLRUCache(cacheSize),
+	}
+}
+
+func (ct *CacheTest) deleter(key []byte, value any) {
+	ct.deletedKeys = append(ct.deletedKeys, decodeKey(key))
+	ct.deletedVals = append(ct.deletedVals, value.(int))
+}
+
+func (ct *CacheTest) Lookup(key int) int {
+	handle := ct.cache.Lookup(encodeKey(key))
+	if handle == nil {
+		return -1
+	}
+	r := ct.cache.Value(handle).(int)
+	ct.cache.Release(handle)
+	return r
+}
+
+func (ct *CacheTest) Insert(key, value int) {
+	ct.InsertWithCharge(key, value, 1)
+}
+
+func (ct *CacheTest) InsertWithCharge(key, value int, charge uint64) {
+	ct.cache.Release(ct.InsertAndReturnHandle(key, value, charge))
+}
+
+func (ct *CacheTest) InsertAndReturnHandle(key, value int, charge uint64) *CacheHandle {
+	return ct.cache.Insert(encodeKey(key), value, charge, ct.deleter)
+}
+
+func (ct *CacheTest) Erase(key int) {
+	ct.cache.Erase(encodeKey(key))
+}
+
+func TestHitAndMiss(t *testing.T) {
+	ct := NewCacheTest()
+	assert.Equal(t, -1, ct.Lookup(100))
+
+	ct.Insert(100, 101)
+	assert.Equal(t, 101, ct.Lookup(100))
+	assert.Equal(t, -1, ct.Lookup(200))
+	assert.Equal(t, -1, ct.Lookup(300))
+
+	ct.Insert(200, 201)
+	assert.Equal(t, 101, ct.Lookup(100))
+	assert.Equal(t, 201, ct.Lookup(200))
+	assert.Equal(t, -1, ct.Lookup(300))
+
+	ct.Insert(100, 102)
+	assert.Equal(t, 102, ct.Lookup(100))
+	assert.Equal(t, 201, ct.Lookup(200))
+	assert.Equal(t, -1, ct.Lookup(300))
+
+	assert.Equal(t, []int{100}, ct.deletedKeys)
+	assert.Equal(t, []int{101}, ct.deletedVals)
+}
+
+func TestErase(t *testing.T) {
+	ct := NewCacheTest()
+	ct.Erase(200)
+	assert.Empty(t, ct.deletedKeys)
+
+	ct.Insert(100, 101)
+	ct.Insert(200, 201)
+	ct.Erase(100)
+	assert.Equal(t, -1, ct.Lookup(100))
+	assert.Equal(t, 201, ct.Lookup(200))
+	assert.Equal(t, []int{100}, ct.deletedKeys)
+	assert.Equal(t, []int{101}, ct.deletedVals)
+
+	ct.Erase(100)
+	assert.Equal(t, -1, ct.Lookup(100))
+	assert.Equal(t, 201, ct.Lookup(200))
+	assert.Len(t, ct.deletedKeys, 1)
+}
+
+func TestEntriesArePinned(t *testing.T) {
+	ct := NewCacheTest()
+	ct.Insert(100, 101)
+	h1 := ct.cache.Lookup(encodeKey(100))
+	assert.Equal(t, 101, ct.cache.Value(h1))
+
+	ct.Insert(100, 102)
+	h2 := ct.cache.Lookup(encodeKey(100))
+	assert.Equal(t, 102, ct.cache.Value(h2))
+	assert.Empty(t, ct.deletedKeys)
+
+	ct.cache.Release(h1)
+	assert.Equal(t, []int{100}, ct.deletedKeys)
+	assert.Equal(t, []int{101}, ct.deletedVals)
+
+	ct.Erase(100)
+	assert.Equal(t, -1, ct.Lookup(100))
+	assert.Len(t, ct.deletedKeys, 1)
+
+	ct.cache.Release(h2)
+	assert.Equal(t, []int{100, 100}, ct.deletedKeys)
+	assert.Equal(t, []int{101, 102}, ct.deletedVals)
+}
+
+func TestEvictionPolicy(t *testing.T) {
+	ct := NewCacheTest()
+	ct.Insert(100, 101)
+	ct.Insert(200, 201)
+	ct.Insert(300, 301)
+	h := ct.cache.Lookup(encodeKey(300))
+
+	// Frequently used entry must be kept around,
+	// as must things that are still in use.
+	// Capacity is split across shards, so overfill enough to reach every shard.
+	for i := 0; i < 2*cacheSize; i++ {
+		ct.Insert(1000+i, 2000+i)
+		assert.Equal(t, 2000+i, ct.Lookup(1000+i))
+		assert.Equal(t, 101, ct.Lookup(100))
+	}
+	assert.Equal(t, 101, ct.Lookup(100))
+	assert.Equal(t, -1, ct.Lookup(200))
+	assert.Equal(t, 301, ct.Lookup(300))
+	ct.cache.Release(h)
+}
+
+func TestUseExceedsCacheSize(t *testing.T) {
+	ct := NewCacheTest()
+	// Overfill the cache, keeping handles on all inserted entries.
+	handles := make([]*CacheHandle, 0, cacheSize+100)
+	for i := 0; i < cacheSize+100; i++ {
+		handles = append(handles, ct.InsertAndReturnHandle(1000+i, 2000+i, 1))
+	}
+
+	// Check that all the entries can be found in the cache.
+	for i := range handles {
+		assert.Equal(t, 2000+i, ct.Lookup(1000+i))
+	}
+
+	for _, h := range handles {
+		ct.cache.Release(h)
+	}
+}
+
+func TestHeavyEntries(t *testing.T) {
+	ct := NewCacheTest()
+	// Add a bunch of light and heavy entries and then count the combined
+	// size of items still in the cache, which must be approximately the
+	// same as the total capacity.
+	const light, heavy = 1, 10
+	added, index := 0, 0
+	for added < 2*cacheSize {
+		weight := light
+		if index&1 != 0 {
+			weight = heavy
+		}
+		ct.InsertWithCharge(index, 1000+index, uint64(weight))
+		added += weight
+		index++
+	}
+
+	cachedWeight := 0
+	for i := 0; i < index; i++ {
+		weight := light
+		if i&1 != 0 {
+			weight = heavy
+		}
+		r := ct.Lookup(i)
+		if r >= 0 {
+			cachedWeight += weight
+			assert.Equal(t, 1000+i, r)
+		}
+	}
+	assert.LessOrEqual(t, cachedWeight, cacheSize+cacheSize/10)
+}
+
+func TestNewId(t *testing.T) {
+	ct := NewCacheTest()
+	a := ct.cache.NewId()
+	b := ct.cache.NewId()
+	assert.NotEqual(t, a, b)
+}
+
+func TestPrune(t *testing.T) {
+	ct := NewCacheTest()
+	ct.Insert(1, 100)
+	ct.Insert(2, 200)
+
+	handle := ct.cache.Lookup(encodeKey(1))
+	assert.NotNil(t, handle)
+	ct.cache.Prune()
+	ct.cache.Release(handle)
+
+	assert.Equal(t, 100, ct.Lookup(1))
+	assert.Equal(t, -1, ct.Lookup(2))
+}
+
+func TestZeroSizeCache(t *testing.T) {
+	ct := NewCacheTest()
+	ct.cache = NewLRUCache(0)
+
+	ct.Insert(1, 100)
+	assert.Equal(t, -1, ct.Lookup(1))
+	assert.Equal(t, []int{1}, ct.deletedKeys)
+}
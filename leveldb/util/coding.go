@@ -29,10 +29,22 @@ func EncodeVarInt32(data []byte, value uint32) {
 	}
 }
 
+func EncodeVarInt64(data []byte, value uint64) {
+	const B = 128
+	idx := 0
+	for value >= B {
+		data[idx] = uint8(value) | B
+		value >>= 7
+		idx++
+	}
+	data[idx] = uint8(value)
+}
+
 func EncodeFixedUint64(data []byte, value uint64) {
 	binary.LittleEndian.PutUint64(data, value)
 }
 
+// DecodeFixedUint64 REQUIRES: len(data) >= 8
 func DecodeFixedUint64(data []byte) uint64 {
 	return binary.LittleEndian.Uint64(data)
 }
@@ -41,6 +53,7 @@ func EncodeFixedUint32(data []byte, value uint32) {
 	binary.LittleEndian.PutUint32(data, value)
 }
 
+// DecodeFixedUint32 REQUIRES: len(data) >= 4
 func DecodeFixedUint32(data []byte) uint32 {
 	return binary.LittleEndian.Uint32(data)
 }
@@ -54,15 +67,75 @@ func VarIntLength(value uint64) uint32 {
 	return length
 }
 
-func DecodeVarInt32(data []byte) (value uint32, size uint32) {
-	shift := 0
-	for i := 0; i < len(data) && shift <= 28; i++ {
-		value |= uint32(data[i]&127) << shift
+// DecodeVarInt32 Returns the value, the number of bytes consumed, and a Corruption error
+// (ErrBadVarInt) if data ends in the middle of the varint or it doesn't fit in 32 bits.
+func DecodeVarInt32(data []byte) (value uint32, size uint32, err error) {
+	v, size, err := decodeVarInt(data, 32)
+	return uint32(v), size, err
+}
+
+func DecodeVarInt64(data []byte) (value uint64, size uint32, err error) {
+	return decodeVarInt(data, 64)
+}
+
+// decodeVarInt 解码一个最多 bits 位的 varint，输入被截断或者超长时返回错误
+func decodeVarInt(data []byte, bits uint) (value uint64, size uint32, err error) {
+	for shift := uint(0); shift < bits; shift += 7 {
+		if int(size) >= len(data) {
+			return value, size, NewLevelDbError(ErrBadVarInt, "truncated varint%d", bits)
+		}
+		b := data[size]
 		size += 1
-		if (data[i] & 128) == 0 {
-			return value, size
+		value |= uint64(b&127) << shift
+		if (b & 128) == 0 {
+			if shift+7 > bits && b>>(bits-shift) != 0 {
+				return value, size, NewLevelDbError(ErrBadVarInt, "varint%d overflows", bits)
+			}
+			return value, size, nil
 		}
-		shift += 7
 	}
-	return value, size
+	return value, size, NewLevelDbError(ErrBadVarInt, "varint%d is too long", bits)
+}
+
+// PutFixed32 Appends value to dst, growing it if needed, and returns the extended buffer.
+func PutFixed32(dst []byte, value uint32) []byte {
+	return binary.LittleEndian.AppendUint32(dst, value)
+}
+
+func PutFixed64(dst []byte, value uint64) []byte {
+	return binary.LittleEndian.AppendUint64(dst, value)
+}
+
+func PutVarInt32(dst []byte, value uint32) []byte {
+	return PutVarInt64(dst, uint64(value))
+}
+
+func PutVarInt64(dst []byte, value uint64) []byte {
+	const B = 128
+	for value >= B {
+		dst = append(dst, uint8(value)|B)
+		value >>= 7
+	}
+	return append(dst, uint8(value))
+}
+
+// PutLengthPrefixedSlice Appends varint32(len(value)) followed by value.
+func PutLengthPrefixedSlice(dst []byte, value []byte) []byte {
+	dst = PutVarInt32(dst, uint32(len(value)))
+	return append(dst, value...)
+}
+
+// GetLengthPrefixedSlice Decodes a slice written by PutLengthPrefixedSlice at the start of data.
+// Returns the slice and the number of bytes consumed, or a Corruption error if data is truncated
+// or the length is malformed.
+func GetLengthPrefixedSlice(data []byte) ([]byte, uint32, error) {
+	length, lengthSize, err := decodeVarInt(data, 32)
+	if err != nil {
+		return nil, 0, err
+	}
+	if uint64(len(data))-uint64(lengthSize) < length {
+		return nil, 0, NewLevelDbError(ErrBadLengthPrefixedSlice,
+			"length-prefixed slice needs %d bytes, only %d left", length, uint64(len(data))-uint64(lengthSize))
+	}
+	return data[lengthSize : uint64(lengthSize)+length], lengthSize + uint32(length), nil
 }
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"bytes"
 	"math/rand"
 	"testing"
 	"time"
@@ -17,8 +18,148 @@ func TestUint32(t *testing.T) {
 		value := rnd.Uint32()
 		encodeLength := VarIntLength(uint64(value))
 		EncodeVarInt32(data, value)
-		decodeValue, decodeLength := DecodeVarInt32(data)
+		decodeValue, decodeLength, err := DecodeVarInt32(data)
+		assert.Nil(t, err)
 		assert.Equal(t, value, decodeValue)
 		assert.Equal(t, encodeLength, decodeLength)
 	}
 }
+
+func TestVarInt64(t *testing.T) {
+	values := []uint64{0, 100, ^uint64(0), ^uint64(0) - 1}
+	for k := uint(0); k < 64; k++ {
+		// Test values near powers of two
+		power := uint64(1) << k
+		values = append(values, power, power-1, power+1)
+	}
+
+	data := make([]byte, 0)
+	for _, v := range values {
+		data = PutVarInt64(data, v)
+	}
+
+	offset := uint32(0)
+	for _, v := range values {
+		decodeValue, decodeLength, err := DecodeVarInt64(data[offset:])
+		assert.Nil(t, err)
+		assert.Equal(t, v, decodeValue)
+		assert.Equal(t, VarIntLength(v), decodeLength)
+
+		encoded := make([]byte, 10)
+		EncodeVarInt64(encoded, v)
+		assert.Equal(t, data[offset:offset+decodeLength], encoded[:decodeLength])
+		offset += decodeLength
+	}
+	assert.Equal(t, uint32(len(data)), offset)
+}
+
+func TestPutFixedAndVarInt32(t *testing.T) {
+	data := make([]byte, 0, 1) // grows as values are appended
+	for v := uint32(0); v < 100000; v += 7 {
+		data = PutFixed32(data, v)
+		data = PutVarInt32(data, v)
+		data = PutFixed64(data, uint64(v)<<32)
+	}
+
+	for v := uint32(0); v < 100000; v += 7 {
+		assert.Equal(t, v, DecodeFixedUint32(data))
+		data = data[4:]
+		decodeValue, decodeLength, err := DecodeVarInt32(data)
+		assert.Nil(t, err)
+		assert.Equal(t, v, decodeValue)
+		data = data[decodeLength:]
+		assert.Equal(t, uint64(v)<<32, DecodeFixedUint64(data))
+		data = data[8:]
+	}
+	assert.Empty(t, data)
+}
+
+func TestLengthPrefixedSlice(t *testing.T) {
+	data := PutLengthPrefixedSlice(nil, []byte(""))
+	data = PutLengthPrefixedSlice(data, []byte("foo"))
+	data = PutLengthPrefixedSlice(data, bytes.Repeat([]byte("x"), 200))
+
+	slice, size, err := GetLengthPrefixedSlice(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(""), slice)
+	data = data[size:]
+	slice, size, err = GetLengthPrefixedSlice(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("foo"), slice)
+	data = data[size:]
+	slice, size, err = GetLengthPrefixedSlice(data)
+	assert.Nil(t, err)
+	assert.Equal(t, bytes.Repeat([]byte("x"), 200), slice)
+	assert.Equal(t, uint32(len(data)), size)
+
+	// Truncated payload
+	_, _, err = GetLengthPrefixedSlice(data[:len(data)-1])
+	assert.Equal(t, ErrBadLengthPrefixedSlice, GetErrorNo(err))
+	assert.ErrorIs(t, err, ErrCorruption)
+	// Truncated length
+	_, _, err = GetLengthPrefixedSlice(data[:1])
+	assert.Equal(t, ErrBadVarInt, GetErrorNo(err))
+	_, _, err = GetLengthPrefixedSlice(nil)
+	assert.Equal(t, ErrBadVarInt, GetErrorNo(err))
+	// Overlong length
+	_, _, err = GetLengthPrefixedSlice([]byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x01})
+	assert.Equal(t, ErrBadVarInt, GetErrorNo(err))
+	// Length overflows 32 bits
+	_, _, err = GetLengthPrefixedSlice([]byte{0xff, 0xff, 0xff, 0xff, 0x1f})
+	assert.Equal(t, ErrBadVarInt, GetErrorNo(err))
+}
+
+func TestDecodeVarIntCorruption(t *testing.T) {
+	cases := [][]byte{
+		nil,
+		{0x80},                               // truncated
+		{0x81, 0x82},                         // truncated
+		{0xff, 0xff, 0xff, 0xff, 0x10},       // overflows 32 bits
+		{0x80, 0x80, 0x80, 0x80, 0x80, 0x00}, // too long
+	}
+	for _, data := range cases {
+		_, _, err := DecodeVarInt32(data)
+		assert.Equalf(t, ErrBadVarInt, GetErrorNo(err), "data = %v", data)
+		assert.ErrorIs(t, err, ErrCorruption)
+	}
+
+	value, size, err := DecodeVarInt32([]byte{0xff, 0xff, 0xff, 0xff, 0x0f})
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0xffffffff), value)
+	assert.Equal(t, uint32(5), size)
+
+	_, _, err = DecodeVarInt64([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02})
+	assert.Equal(t, ErrBadVarInt, GetErrorNo(err))
+	value64, size, err := DecodeVarInt64([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01})
+	assert.Nil(t, err)
+	assert.Equal(t, ^uint64(0), value64)
+	assert.Equal(t, uint32(10), size)
+}
+
+func FuzzDecodeVarInt(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0x7f})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0x0f})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Padded encodings such as {0x80, 0x00} are accepted, so re-encoding
+		// may be shorter than the input but must decode to the same value.
+		if value, size, err := DecodeVarInt32(data); err == nil {
+			assert.LessOrEqual(t, int(size), len(data))
+			encoded := PutVarInt32(nil, value)
+			assert.LessOrEqual(t, len(encoded), int(size))
+			decoded, _, err := DecodeVarInt32(encoded)
+			assert.Nil(t, err)
+			assert.Equal(t, value, decoded)
+		}
+		if value, size, err := DecodeVarInt64(data); err == nil {
+			assert.LessOrEqual(t, int(size), len(data))
+			encoded := PutVarInt64(nil, value)
+			assert.LessOrEqual(t, len(encoded), int(size))
+		}
+		if slice, size, err := GetLengthPrefixedSlice(data); err == nil {
+			assert.LessOrEqual(t, int(size), len(data))
+			assert.Equal(t, data[size-uint32(len(slice)):size], slice)
+		}
+	})
+}
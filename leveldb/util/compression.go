@@ -0,0 +1,117 @@
+package util
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// CompressionType DB contents are stored in a set of blocks, each of which holds a
+// sequence of key,value pairs. Each block may be compressed before
+// being stored in a file. The following enum describes which
+// compression method (if any) is used to compress a block.
+// The values are stored in the block trailer, don't change them.
+type CompressionType uint8
+
+const (
+	NoCompression     CompressionType = 0x0
+	SnappyCompression CompressionType = 0x1
+	ZstdCompression   CompressionType = 0x2
+)
+
+func (t CompressionType) String() string {
+	switch t {
+	case NoCompression:
+		return "NoCompression"
+	case SnappyCompression:
+		return "SnappyCompression"
+	case ZstdCompression:
+		return "ZstdCompression"
+	default:
+		return fmt.Sprintf("CompressionType(%d)", uint8(t))
+	}
+}
+
+// BlockTrailerSize 1-byte type + 32-bit crc
+const BlockTrailerSize = 5
+
+// 编码器和解码器都可以被多个 goroutine 同时使用（EncodeAll / DecodeAll），只初始化一次
+// 没有传入 option，创建时不会返回错误
+var (
+	zstdEncoder = sync.OnceValue(func() *zstd.Encoder {
+		encoder, _ := zstd.NewWriter(nil)
+		return encoder
+	})
+	zstdDecoder = sync.OnceValue(func() *zstd.Decoder {
+		decoder, _ := zstd.NewReader(nil)
+		return decoder
+	})
+)
+
+// EncodeBlock Appends raw, compressed with compressionType, and the block trailer to dst.
+// raw is stored uncompressed if compression saves less than 12.5% or compressionType is unknown.
+// The trailer holds the type actually used and the masked crc32c of the stored contents and type.
+func EncodeBlock(dst []byte, raw []byte, compressionType CompressionType) []byte {
+	var compressed []byte
+	switch compressionType {
+	case SnappyCompression:
+		compressed = snappy.Encode(nil, raw)
+	case ZstdCompression:
+		compressed = zstdEncoder().EncodeAll(raw, nil)
+	}
+
+	contents := raw
+	if compressed != nil && len(compressed) < len(raw)-len(raw)/8 {
+		contents = compressed
+	} else {
+		compressionType = NoCompression
+	}
+
+	dst = append(dst, contents...)
+	dst = append(dst, byte(compressionType))
+	crc := Crc32cValue(contents)
+	crc = Crc32cExtend(crc, []byte{byte(compressionType)}) // Extend crc to cover block type
+	return PutFixed32(dst, Mask(crc))
+}
+
+// DecodeBlock Returns the uncompressed contents of a block written by EncodeBlock.
+// Returns a Corruption error if block is truncated, fails the checksum (when verifyChecksum)
+// or can't be decompressed.
+func DecodeBlock(block []byte, verifyChecksum bool) ([]byte, error) {
+	if len(block) < BlockTrailerSize {
+		return nil, NewLevelDbError(ErrTruncatedBlock, "block of %d bytes is shorter than its trailer", len(block))
+	}
+	n := len(block) - BlockTrailerSize
+	contents := block[:n]
+	compressionType := CompressionType(block[n])
+
+	if verifyChecksum {
+		expectedCrc := Unmask(DecodeFixedUint32(block[n+1:]))
+		actualCrc := Crc32cValue(block[:n+1])
+		if expectedCrc != actualCrc {
+			return nil, NewLevelDbError(ErrCheckCrcFailed, "block checksum mismatch, expect crc: %d, actual crc: %d",
+				expectedCrc, actualCrc)
+		}
+	}
+
+	switch compressionType {
+	case NoCompression:
+		return contents, nil
+	case SnappyCompression:
+		raw, err := snappy.Decode(nil, contents)
+		if err != nil {
+			return nil, WrapLevelDbError(ErrBadCompressedBlock, err, "corrupted snappy compressed block contents")
+		}
+		return raw, nil
+	case ZstdCompression:
+		raw, err := zstdDecoder().DecodeAll(contents, nil)
+		if err != nil {
+			return nil, WrapLevelDbError(ErrBadCompressedBlock, err, "corrupted zstd compressed block contents")
+		}
+		return raw, nil
+	default:
+		return nil, NewLevelDbError(ErrBadBlockType, "bad block type %d", compressionType)
+	}
+}
@@ -0,0 +1,104 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func compressibleBlock(n int) []byte {
+	var buf bytes.Buffer
+	for i := 0; buf.Len() < n; i++ {
+		fmt.Fprintf(&buf, `{"id":%d,"name":"user%d","active":true}`, i, i%10)
+	}
+	return buf.Bytes()[:n]
+}
+
+func randomBlock(n int) []byte {
+	rnd := rand.New(rand.NewSource(301))
+	data := make([]byte, n)
+	rnd.Read(data)
+	return data
+}
+
+func TestEncodeDecodeBlock(t *testing.T) {
+	raw := compressibleBlock(4096)
+	for _, compressionType := range []CompressionType{NoCompression, SnappyCompression, ZstdCompression} {
+		block := EncodeBlock(nil, raw, compressionType)
+		assert.Equal(t, byte(compressionType), block[len(block)-BlockTrailerSize], compressionType.String())
+		if compressionType != NoCompression {
+			assert.Less(t, len(block), len(raw)/2, compressionType.String())
+		}
+
+		decoded, err := DecodeBlock(block, true)
+		assert.Nil(t, err)
+		assert.Equal(t, raw, decoded)
+	}
+
+	// Empty block
+	decoded, err := DecodeBlock(EncodeBlock(nil, nil, SnappyCompression), true)
+	assert.Nil(t, err)
+	assert.Empty(t, decoded)
+
+	// dst is appended to
+	block := EncodeBlock([]byte("prefix"), raw, ZstdCompression)
+	assert.Equal(t, []byte("prefix"), block[:6])
+	decoded, err = DecodeBlock(block[6:], true)
+	assert.Nil(t, err)
+	assert.Equal(t, raw, decoded)
+}
+
+func TestEncodeBlockFallback(t *testing.T) {
+	// Incompressible contents are stored as is
+	raw := randomBlock(4096)
+	for _, compressionType := range []CompressionType{SnappyCompression, ZstdCompression, CompressionType(100)} {
+		block := EncodeBlock(nil, raw, compressionType)
+		assert.Equal(t, len(raw)+BlockTrailerSize, len(block))
+		assert.Equal(t, byte(NoCompression), block[len(raw)])
+		assert.Equal(t, raw, block[:len(raw)])
+	}
+
+	// Saving less than 12.5% is not worth it: 1/8 compressible, 7/8 random
+	raw = append(bytes.Repeat([]byte{'a'}, 512), randomBlock(3584)...)
+	block := EncodeBlock(nil, raw, SnappyCompression)
+	assert.Equal(t, byte(NoCompression), block[len(block)-BlockTrailerSize])
+	// 1/4 compressible is
+	raw = append(bytes.Repeat([]byte{'a'}, 1024), randomBlock(3072)...)
+	block = EncodeBlock(nil, raw, SnappyCompression)
+	assert.Equal(t, byte(SnappyCompression), block[len(block)-BlockTrailerSize])
+}
+
+func TestDecodeBlockCorruption(t *testing.T) {
+	raw := compressibleBlock(4096)
+	block := EncodeBlock(nil, raw, SnappyCompression)
+	n := len(block) - BlockTrailerSize
+
+	_, err := DecodeBlock(block[:BlockTrailerSize-1], true)
+	assert.ErrorIs(t, err, ErrCorruption)
+	assert.Equal(t, ErrTruncatedBlock, GetErrorNo(err))
+
+	// The checksum covers the contents
+	corrupted := bytes.Clone(block)
+	corrupted[n/2]++
+	_, err = DecodeBlock(corrupted, true)
+	assert.Equal(t, ErrCheckCrcFailed, GetErrorNo(err))
+
+	// and the type byte
+	corrupted = bytes.Clone(block)
+	corrupted[n] = byte(ZstdCompression)
+	_, err = DecodeBlock(corrupted, true)
+	assert.Equal(t, ErrCheckCrcFailed, GetErrorNo(err))
+
+	// Without checksum verification, bad contents are reported by the decompressor
+	_, err = DecodeBlock(corrupted, false)
+	assert.ErrorIs(t, err, ErrCorruption)
+	assert.Equal(t, ErrBadCompressedBlock, GetErrorNo(err))
+
+	corrupted[n] = 100
+	_, err = DecodeBlock(corrupted, false)
+	assert.ErrorIs(t, err, ErrCorruption)
+	assert.Equal(t, ErrBadBlockType, GetErrorNo(err))
+}
@@ -2,6 +2,7 @@ package util
 
 import "hash/crc32"
 
+// Crc32Value IEEE CRC32, only used to read logs written before the switch to CRC32C.
 func Crc32Value(data []byte) uint32 {
 	return crc32.ChecksumIEEE(data)
 }
@@ -9,3 +10,35 @@ func Crc32Value(data []byte) uint32 {
 func Crc32ValueWithInitial(initial uint32, data []byte) uint32 {
 	return crc32.Update(initial, crc32.IEEETable, data)
 }
+
+// hash/crc32 使用 SSE4.2 / ARMv8 的 CRC32 指令计算 Castagnoli 多项式
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Crc32cValue Returns the crc32c of data, same as crc32c::Value in LevelDB.
+func Crc32cValue(data []byte) uint32 {
+	return crc32.Checksum(data, castagnoliTable)
+}
+
+// Crc32cExtend Returns the crc32c of concat(A, data) where initial is the crc32c of some string A.
+// Extend() is often used to maintain the crc32c of a stream of data.
+func Crc32cExtend(initial uint32, data []byte) uint32 {
+	return crc32.Update(initial, castagnoliTable, data)
+}
+
+const maskDelta uint32 = 0xa282ead8
+
+// Mask Returns a masked representation of crc.
+//
+// Motivation: it is problematic to compute the CRC of a string that
+// contains embedded CRCs. Therefore we recommend that CRCs stored
+// somewhere (e.g., in files) should be masked before being stored.
+func Mask(crc uint32) uint32 {
+	// Rotate right by 15 bits and add a constant.
+	return ((crc >> 15) | (crc << 17)) + maskDelta
+}
+
+// Unmask Return the crc whose masked representation is maskedCrc.
+func Unmask(maskedCrc uint32) uint32 {
+	rot := maskedCrc - maskDelta
+	return (rot >> 17) | (rot << 15)
+}
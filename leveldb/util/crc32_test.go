@@ -14,3 +14,50 @@ func TestUpdateCrc32(t *testing.T) {
 
 	assert.Equal(t, v1, v3)
 }
+
+func TestCrc32cStandardResults(t *testing.T) {
+	// From rfc3720 section B.4.
+	buf := make([]byte, 32)
+	assert.Equal(t, uint32(0x8a9136aa), Crc32cValue(buf))
+
+	for i := range buf {
+		buf[i] = 0xff
+	}
+	assert.Equal(t, uint32(0x62a8ab43), Crc32cValue(buf))
+
+	for i := range buf {
+		buf[i] = byte(i)
+	}
+	assert.Equal(t, uint32(0x46dd794e), Crc32cValue(buf))
+
+	for i := range buf {
+		buf[i] = byte(31 - i)
+	}
+	assert.Equal(t, uint32(0x113fdb5c), Crc32cValue(buf))
+
+	data := []byte{
+		0x01, 0xc0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x14, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04, 0x00,
+		0x00, 0x00, 0x00, 0x14, 0x00, 0x00, 0x00, 0x18, 0x28, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+	assert.Equal(t, uint32(0xd9963a56), Crc32cValue(data))
+}
+
+func TestCrc32cValues(t *testing.T) {
+	assert.NotEqual(t, Crc32cValue([]byte("a")), Crc32cValue([]byte("foo")))
+	assert.NotEqual(t, Crc32Value([]byte("foo")), Crc32cValue([]byte("foo")))
+}
+
+func TestCrc32cExtend(t *testing.T) {
+	assert.Equal(t, Crc32cValue([]byte("hello world")),
+		Crc32cExtend(Crc32cValue([]byte("hello ")), []byte("world")))
+}
+
+func TestCrc32cMask(t *testing.T) {
+	crc := Crc32cValue([]byte("foo"))
+	assert.NotEqual(t, crc, Mask(crc))
+	assert.NotEqual(t, crc, Mask(Mask(crc)))
+	assert.Equal(t, crc, Unmask(Mask(crc)))
+	assert.Equal(t, crc, Unmask(Unmask(Mask(Mask(crc)))))
+}
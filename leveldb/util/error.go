@@ -5,6 +5,49 @@ import (
 	"fmt"
 )
 
+// Category groups error codes the same way LevelDB's Status does, so callers
+// can react to a class of failure without enumerating every ErrorNo.
+type Category uint8
+
+const (
+	CategoryOk Category = iota
+	CategoryNotFound
+	CategoryCorruption
+	CategoryNotSupported
+	CategoryInvalidArgument
+	CategoryIOError
+)
+
+var categoryNames = [...]string{
+	CategoryOk:              "OK",
+	CategoryNotFound:        "NotFound",
+	CategoryCorruption:      "Corruption",
+	CategoryNotSupported:    "Not implemented",
+	CategoryInvalidArgument: "Invalid argument",
+	CategoryIOError:         "IO error",
+}
+
+func (c Category) String() string {
+	if int(c) < len(categoryNames) {
+		return categoryNames[c]
+	}
+	return fmt.Sprintf("Category(%d)", uint8(c))
+}
+
+// Error makes every Category usable as a sentinel with errors.Is.
+func (c Category) Error() string {
+	return c.String()
+}
+
+// Sentinel errors, usage: errors.Is(err, util.ErrCorruption)
+var (
+	ErrNotFound        error = CategoryNotFound
+	ErrCorruption      error = CategoryCorruption
+	ErrNotSupported    error = CategoryNotSupported
+	ErrInvalidArgument error = CategoryInvalidArgument
+	ErrIOError         error = CategoryIOError
+)
+
 type ErrorNo uint32
 
 const (
@@ -21,11 +64,66 @@ const (
 	ErrMissingStart
 	ErrInMiddleRecord
 	ErrPartialRecordWithoutEnd
+	ErrMergeOperatorMissing
+	ErrMergeFailed
+	ErrDuplicateEntry
+	ErrBadVarInt
+	ErrBadLengthPrefixedSlice
+	ErrBadInternalKey
+	ErrInvalidRange
+	ErrTruncatedBlock
+	ErrBadBlockType
+	ErrBadCompressedBlock
 )
 
+var errorNoInfos = [...]struct {
+	name     string
+	category Category
+}{
+	ErrOk:                      {"Ok", CategoryOk},
+	ErrUnknown:                 {"Unknown", CategoryIOError},
+	ErrWriteFileFailed:         {"WriteFileFailed", CategoryIOError},
+	ErrFlushFileFailed:         {"FlushFileFailed", CategoryIOError},
+	ErrSyncFileFailed:          {"SyncFileFailed", CategoryIOError},
+	ErrSeekFileFailed:          {"SeekFileFailed", CategoryIOError},
+	ErrCheckCrcFailed:          {"CheckCrcFailed", CategoryCorruption},
+	ErrReadFileFailed:          {"ReadFileFailed", CategoryIOError},
+	ErrUnknownRecordType:       {"UnknownRecordType", CategoryCorruption},
+	ErrBadRecordLength:         {"BadRecordLength", CategoryCorruption},
+	ErrMissingStart:            {"MissingStart", CategoryCorruption},
+	ErrInMiddleRecord:          {"InMiddleRecord", CategoryCorruption},
+	ErrPartialRecordWithoutEnd: {"PartialRecordWithoutEnd", CategoryCorruption},
+	ErrMergeOperatorMissing:    {"MergeOperatorMissing", CategoryInvalidArgument},
+	ErrMergeFailed:             {"MergeFailed", CategoryCorruption},
+	ErrDuplicateEntry:          {"DuplicateEntry", CategoryInvalidArgument},
+	ErrBadVarInt:               {"BadVarInt", CategoryCorruption},
+	ErrBadLengthPrefixedSlice:  {"BadLengthPrefixedSlice", CategoryCorruption},
+	ErrBadInternalKey:          {"BadInternalKey", CategoryCorruption},
+	ErrInvalidRange:            {"InvalidRange", CategoryInvalidArgument},
+	ErrTruncatedBlock:          {"TruncatedBlock", CategoryCorruption},
+	ErrBadBlockType:            {"BadBlockType", CategoryCorruption},
+	ErrBadCompressedBlock:      {"BadCompressedBlock", CategoryCorruption},
+}
+
+func (errNo ErrorNo) String() string {
+	if int(errNo) < len(errorNoInfos) {
+		return errorNoInfos[errNo].name
+	}
+	return fmt.Sprintf("ErrorNo(%d)", uint32(errNo))
+}
+
+// Category Returns the category errNo belongs to. Unknown codes are treated as IO errors.
+func (errNo ErrorNo) Category() Category {
+	if int(errNo) < len(errorNoInfos) {
+		return errorNoInfos[errNo].category
+	}
+	return CategoryIOError
+}
+
 type LevelDbError struct {
 	errorNo ErrorNo
 	msg     string
+	cause   error
 }
 
 func NewLevelDbError(errNo ErrorNo, msg string, args ...any) *LevelDbError {
@@ -35,8 +133,44 @@ func NewLevelDbError(errNo ErrorNo, msg string, args ...any) *LevelDbError {
 	}
 }
 
+// WrapLevelDbError is like NewLevelDbError, but keeps cause (usually an OS error)
+// reachable through errors.Is / errors.As.
+func WrapLevelDbError(errNo ErrorNo, cause error, msg string, args ...any) *LevelDbError {
+	err := NewLevelDbError(errNo, msg, args...)
+	err.cause = cause
+	return err
+}
+
 func (err *LevelDbError) Error() string {
-	return fmt.Sprintf("ErrorNo: %d, Msg: %s", err.errorNo, err.msg)
+	s := fmt.Sprintf("%s: %s: %s", err.errorNo.Category(), err.errorNo, err.msg)
+	if err.cause != nil {
+		s += ": " + err.cause.Error()
+	}
+	return s
+}
+
+func (err *LevelDbError) Unwrap() error {
+	return err.cause
+}
+
+// Is reports whether target is the sentinel of err's category,
+// or a *LevelDbError carrying the same ErrorNo.
+func (err *LevelDbError) Is(target error) bool {
+	switch t := target.(type) {
+	case Category:
+		return t == err.errorNo.Category()
+	case *LevelDbError:
+		return t.errorNo == err.errorNo
+	}
+	return false
+}
+
+func (err *LevelDbError) ErrorNo() ErrorNo {
+	return err.errorNo
+}
+
+func (err *LevelDbError) Category() Category {
+	return err.errorNo.Category()
 }
 
 func GetErrorNo(err error) ErrorNo {
@@ -50,3 +184,8 @@ func GetErrorNo(err error) ErrorNo {
 	}
 	return levelDbErr.errorNo
 }
+
+// GetCategory Returns the category of err. Errors not produced by this package are IO errors.
+func GetCategory(err error) Category {
+	return GetErrorNo(err).Category()
+}
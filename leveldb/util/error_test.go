@@ -0,0 +1,36 @@
+package util
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorCategory(t *testing.T) {
+	err := NewLevelDbError(ErrCheckCrcFailed, "expect crc: %d, actual crc: %d", 1, 2)
+	assert.True(t, errors.Is(err, ErrCorruption))
+	assert.False(t, errors.Is(err, ErrIOError))
+	assert.True(t, errors.Is(err, NewLevelDbError(ErrCheckCrcFailed, "")))
+	assert.False(t, errors.Is(err, NewLevelDbError(ErrBadRecordLength, "")))
+	assert.Equal(t, CategoryCorruption, GetCategory(err))
+	assert.Equal(t, "Corruption: CheckCrcFailed: expect crc: 1, actual crc: 2", err.Error())
+}
+
+func TestWrapError(t *testing.T) {
+	err := WrapLevelDbError(ErrReadFileFailed, io.ErrUnexpectedEOF, "failed to read file")
+	assert.True(t, errors.Is(err, ErrIOError))
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+	assert.Equal(t, io.ErrUnexpectedEOF, errors.Unwrap(err))
+	assert.Equal(t, ErrReadFileFailed, GetErrorNo(err))
+	assert.Equal(t, "IO error: ReadFileFailed: failed to read file: unexpected EOF", err.Error())
+}
+
+func TestErrorNo(t *testing.T) {
+	assert.Equal(t, ErrOk, GetErrorNo(nil))
+	assert.Equal(t, ErrUnknown, GetErrorNo(io.EOF))
+	assert.Equal(t, CategoryIOError, GetCategory(io.EOF))
+	assert.Equal(t, "PartialRecordWithoutEnd", ErrPartialRecordWithoutEnd.String())
+	assert.Equal(t, "ErrorNo(1000)", ErrorNo(1000).String())
+}
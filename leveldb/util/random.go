@@ -0,0 +1,60 @@
+package util
+
+// Random A very simple random number generator. Not especially good at
+// generating truly random bits, but good enough for our needs in this
+// package. Not thread-safe.
+type Random struct {
+	seed uint32
+}
+
+func NewRandom(s uint32) *Random {
+	seed := s & 0x7fffffff
+	// Avoid bad seeds.
+	if seed == 0 || seed == 2147483647 {
+		seed = 1
+	}
+	return &Random{
+		seed: seed,
+	}
+}
+
+func (r *Random) Next() uint32 {
+	const M uint32 = 2147483647 // 2^31-1
+	const A uint64 = 16807      // bits 14, 8, 7, 5, 2, 1, 0
+	// We are computing
+	//       seed = (seed * A) % M,    where M = 2^31-1
+	//
+	// seed must not be zero or M, or else all subsequent computed values
+	// will be zero or M respectively.  For all other values, seed will end
+	// up cycling through every number in [1,M-1]
+	product := uint64(r.seed) * A
+
+	// Compute (product % M) using the fact that ((x << 31) % M) == x.
+	r.seed = uint32((product >> 31) + (product & uint64(M)))
+	// The first reduction may overflow by 1 bit, so we may need to
+	// repeat.  mod == M is not possible; using > allows the faster
+	// sign-bit-based test.
+	if r.seed > M {
+		r.seed -= M
+	}
+	return r.seed
+}
+
+// Uniform Returns a uniformly distributed value in the range [0..n-1]
+// REQUIRES: n > 0
+func (r *Random) Uniform(n uint32) uint32 {
+	return r.Next() % n
+}
+
+// OneIn Randomly returns true ~"1/n" of the time, and false otherwise.
+// REQUIRES: n > 0
+func (r *Random) OneIn(n uint32) bool {
+	return r.Next()%n == 0
+}
+
+// Skewed Picks "base" uniformly from range [0,2^maxLog] and then
+// returns "base" random bits.  The effect is to pick a number in the
+// range [0,2^maxLog-1] with exponential bias towards smaller numbers.
+func (r *Random) Skewed(maxLog uint32) uint32 {
+	return r.Uniform(1 << r.Uniform(maxLog+1))
+}
@@ -0,0 +1,26 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomDeterministic(t *testing.T) {
+	r1, r2 := NewRandom(301), NewRandom(301)
+	for i := 0; i < 1000; i++ {
+		assert.Equal(t, r1.Next(), r2.Next())
+	}
+}
+
+func TestRandomRange(t *testing.T) {
+	for _, seed := range []uint32{0, 1, 2147483647, 0xffffffff, 0xdeadbeef} {
+		r := NewRandom(seed)
+		for i := 0; i < 1000; i++ {
+			v := r.Next()
+			assert.Greater(t, v, uint32(0))
+			assert.Less(t, v, uint32(2147483647))
+			assert.Less(t, r.Uniform(10), uint32(10))
+		}
+	}
+}